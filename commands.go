@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// command is a subcommand, run as "pomo NAME [flags] [args]".
+type command struct {
+	name    string
+	args    string // the positional arguments, for usage
+	summary string
+	// flags names the top-level flags that also work after the command
+	// name; allFlags accepts every one of them.
+	flags    []string
+	allFlags bool
+	// run does the command's work. The timer commands have none: main
+	// carries on and starts the timer.
+	run func(env commandEnv, args []string) error
+}
+
+// commandEnv is what the flags settled, for the commands that need it.
+type commandEnv struct {
+	sound   alertSound
+	icon    string
+	clock12 bool
+}
+
+var (
+	errTestFailed   = errors.New("notification test failed")
+	errDoctorFailed = errors.New("notifications aren't working")
+)
+
+// commands are listed in the order usage shows them.
+var commands = []command{
+	{
+		name:     "start",
+		args:     "[work] [break] [sessions] [long break]",
+		summary:  "Run the timer (the default)",
+		allFlags: true,
+	},
+	{
+		name:     "resume",
+		summary:  "Pick up a run that was cut short",
+		allFlags: true,
+	},
+	{
+		name:    "next",
+		summary: "Print the time left until the next break, or the next work session",
+		run: func(_ commandEnv, _ []string) error {
+			return runNext(os.Stdout, time.Now())
+		},
+	},
+	{
+		name:    "stats",
+		summary: "Summarize completed pomodoros",
+		run: func(_ commandEnv, _ []string) error {
+			return runStats(os.Stdout)
+		},
+	},
+	{
+		name:    "streak",
+		summary: "Show how many days in a row you've done a pomodoro",
+		run: func(_ commandEnv, _ []string) error {
+			return runStreak(os.Stdout)
+		},
+	},
+	{
+		name:    "history",
+		args:    "[today | yesterday | YYYY-MM-DD]",
+		summary: "List one day's sessions",
+		flags:   []string{"12h", "24h"},
+		run: func(env commandEnv, args []string) error {
+			day := ""
+			if len(args) > 0 {
+				day = args[0]
+			}
+			return runHistory(os.Stdout, day, env.clock12)
+		},
+	},
+	{
+		name:    "presets",
+		summary: "List the built-in presets",
+		run: func(_ commandEnv, _ []string) error {
+			printPresets(os.Stdout)
+			return nil
+		},
+	},
+	{
+		name:    "sounds",
+		args:    "[theme]",
+		summary: "List the sound themes, or play one",
+		run: func(_ commandEnv, args []string) error {
+			if len(args) == 0 {
+				printSoundThemes(os.Stdout)
+				return nil
+			}
+			st, ok := findSoundTheme(args[0])
+			if !ok {
+				return fmt.Errorf("unknown sound theme %q; 'pomo sounds' lists them", args[0])
+			}
+			return playTones(st.tones)
+		},
+	},
+	{
+		name:    "test",
+		summary: "Play the alert and send a test notification",
+		flags:   []string{"sound", "sound-theme", "beep-freq", "beep-duration", "volume", "icon"},
+		run: func(env commandEnv, _ []string) error {
+			if !runNotificationTest(os.Stdout, env.sound, env.icon) {
+				return errTestFailed
+			}
+			return nil
+		},
+	},
+	{
+		name:    "doctor",
+		summary: "Check the tools alerts depend on and suggest fixes",
+		flags:   []string{"sound", "sound-theme", "icon"},
+		run: func(env commandEnv, _ []string) error {
+			if !runDoctor(os.Stdout, env.sound, env.icon) {
+				return errDoctorFailed
+			}
+			return nil
+		},
+	},
+	{
+		name:    "version",
+		summary: "Print the version and build info",
+		run: func(_ commandEnv, _ []string) error {
+			printVersion(os.Stdout)
+			return nil
+		},
+	},
+}
+
+// findCommand looks a command up by name.
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// flagSet builds c's flags from the top-level ones it accepts. The values
+// are shared, so a flag means the same before or after the command name.
+func (c command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("pomo "+c.name, flag.ExitOnError)
+	flag.VisitAll(func(f *flag.Flag) {
+		if c.allFlags || slices.Contains(c.flags, f.Name) {
+			fs.Var(f.Value, f.Name, f.Usage)
+			fs.Lookup(f.Name).DefValue = f.DefValue
+		}
+	})
+	fs.Usage = func() {
+		w := fs.Output()
+		hasFlags := c.allFlags || len(c.flags) > 0
+		synopsis := "pomo " + c.name
+		if hasFlags {
+			synopsis += " [flags]"
+		}
+		if c.args != "" {
+			synopsis += " " + c.args
+		}
+		fmt.Fprintf(w, "Usage: %s\n\n%s.\n", synopsis, c.summary)
+		if hasFlags {
+			fmt.Fprintln(w, "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// parseCommand picks the command named by args[0] and parses its flags,
+// returning the flag set and the positional arguments left over. With no
+// name, or a duration where the name would be ("pomo 25 5 4"), it's start.
+func parseCommand(args []string) (command, *flag.FlagSet, []string, error) {
+	c, _ := findCommand("start")
+	if len(args) > 0 {
+		if named, ok := findCommand(args[0]); ok {
+			c, args = named, args[1:]
+		} else if _, ok := parseDuration(args[0]); !ok {
+			return command{}, nil, nil, fmt.Errorf("unknown command %q", args[0])
+		}
+	}
+	fs := c.flagSet()
+	fs.Parse(args) // ExitOnError
+	return c, fs, fs.Args(), nil
+}
+
+// usage is the top-level help printed by -h and for unknown commands.
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  pomo [flags] [work] [break] [sessions] [long break]")
+	fmt.Fprintln(w, "  pomo COMMAND [flags] [args]")
+	fmt.Fprintln(w, "\nCommands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "\nRun 'pomo COMMAND -h' for a command's flags.")
+	fmt.Fprintln(w, "\nFlags:")
+	flag.PrintDefaults()
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// config holds the defaults that the config file can change.
+type config struct {
+	work      time.Duration
+	brk       time.Duration
+	longBreak time.Duration
+	sessions  int
+	theme     string
+	sound     bool
+}
+
+// defaultConfig returns the built-in defaults.
+func defaultConfig() config {
+	return config{
+		work:      defaultWork,
+		brk:       defaultBreak,
+		longBreak: defaultLongBreak,
+		sessions:  defaultSessions,
+		theme:     "default",
+		sound:     true,
+	}
+}
+
+// configPath returns $XDG_CONFIG_HOME/pomodoro/config.toml when set,
+// otherwise ~/.pomodoro/config.toml.
+func configPath() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "pomodoro", "config.toml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".pomodoro", "config.toml"), nil
+}
+
+// loadConfig reads the config file over the built-in defaults. A missing
+// file is not an error; on any other error the built-in defaults are
+// returned alongside it.
+func loadConfig() (config, error) {
+	path, err := configPath()
+	if err != nil {
+		return defaultConfig(), nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return defaultConfig(), nil
+	}
+	if err != nil {
+		return defaultConfig(), err
+	}
+	defer f.Close()
+	cfg, err := parseConfig(f)
+	if err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// parseConfig reads the flat subset of TOML we need: one `key = value`
+// per line, optional quotes around values, and # comments.
+func parseConfig(r io.Reader) (config, error) {
+	cfg := defaultConfig()
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			return defaultConfig(), fmt.Errorf("line %d: expected key = value", n)
+		}
+		key = strings.TrimSpace(key)
+		val = strings.TrimSpace(val)
+		if i := strings.Index(val, " #"); i >= 0 {
+			val = strings.TrimSpace(val[:i])
+		}
+		val = strings.Trim(val, `"'`)
+
+		var err error
+		switch key {
+		case "work":
+			cfg.work, err = parseConfigDuration(val)
+			if err == nil && cfg.work <= 0 {
+				err = errors.New("must be positive")
+			}
+		case "break":
+			cfg.brk, err = parseConfigDuration(val)
+		case "long_break":
+			cfg.longBreak, err = parseConfigDuration(val)
+		case "sessions":
+			cfg.sessions, err = strconv.Atoi(val)
+			if err == nil && cfg.sessions <= 0 {
+				err = errors.New("must be positive")
+			}
+		case "theme":
+			cfg.theme = val
+		case "sound":
+			cfg.sound, err = strconv.ParseBool(val)
+		default:
+			err = errors.New("unknown key")
+		}
+		if err != nil {
+			return defaultConfig(), fmt.Errorf("line %d: %s: %v", n, key, err)
+		}
+	}
+	return cfg, sc.Err()
+}
+
+func parseConfigDuration(s string) (time.Duration, error) {
+	d, ok := parseDuration(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// applyEnv layers POMO_WORK, POMO_BREAK and POMO_SESSIONS over cfg. Unset
+// or empty variables are skipped; invalid ones keep cfg's value and are
+// reported in the returned warnings.
+func applyEnv(cfg config, getenv func(string) string) (config, []string) {
+	var warnings []string
+	for _, v := range []struct {
+		name string
+		d    *time.Duration
+	}{{"POMO_WORK", &cfg.work}, {"POMO_BREAK", &cfg.brk}} {
+		s := strings.TrimSpace(getenv(v.name))
+		if s == "" {
+			continue
+		}
+		d, ok := parseDuration(s)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("ignoring %s=%q: not a duration", v.name, s))
+			continue
+		}
+		if d <= 0 && v.d == &cfg.work {
+			warnings = append(warnings, fmt.Sprintf("ignoring %s=%q: must be positive", v.name, s))
+			continue
+		}
+		*v.d = parseDurationInput(s, *v.d)
+	}
+	if s := strings.TrimSpace(getenv("POMO_SESSIONS")); s != "" {
+		if n, err := strconv.Atoi(s); err != nil || n < 1 {
+			warnings = append(warnings, fmt.Sprintf("ignoring POMO_SESSIONS=%q: not a positive number", s))
+		} else {
+			cfg.sessions = n
+		}
+	}
+	return cfg, warnings
+}
+
+// preset is a named work/break/sessions combination.
+type preset struct {
+	name     string
+	work     time.Duration
+	brk      time.Duration
+	sessions int
+}
+
+var presets = []preset{
+	{"classic", 25 * time.Minute, 5 * time.Minute, 4},
+	{"long", 50 * time.Minute, 10 * time.Minute, 4},
+	{"short", 15 * time.Minute, 3 * time.Minute, 6},
+	{"52-17", 52 * time.Minute, 17 * time.Minute, 4},
+}
+
+func findPreset(name string) (preset, bool) {
+	for _, p := range presets {
+		if p.name == strings.ToLower(name) {
+			return p, true
+		}
+	}
+	return preset{}, false
+}
+
+// printPresets lists the available presets, one per line.
+func printPresets(w io.Writer) {
+	for _, p := range presets {
+		fmt.Fprintf(w, "%-8s %s work / %s break × %d\n", p.name, formatDuration(p.work), formatDuration(p.brk), p.sessions)
+	}
+}
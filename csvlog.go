@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// csvHeader names the columns of history.csv, written with -log-format
+// csv for spreadsheets. The first six are what most people want; paused
+// time and skips come last so pomo can read the file back.
+var csvHeader = []string{"timestamp", "phase", "session", "duration_seconds", "interruptions", "task", "paused_seconds", "skipped"}
+
+// csvRow lays rec out in csvHeader order. The phase is "overtime" for
+// overtime and "work" otherwise, since breaks aren't logged.
+func csvRow(rec sessionRecord) []string {
+	phase := "work"
+	if rec.Overtime {
+		phase = "overtime"
+	}
+	return []string{
+		rec.Time.Format(time.RFC3339),
+		phase,
+		strconv.Itoa(rec.Session),
+		strconv.Itoa(rec.Duration),
+		strconv.Itoa(rec.Interruptions),
+		rec.Task,
+		strconv.Itoa(rec.Paused),
+		strconv.FormatBool(rec.Skipped),
+	}
+}
+
+// parseCSVRow reads a csvRow back. Missing trailing columns are zero, so
+// a sheet trimmed to the first six still loads.
+func parseCSVRow(row []string) (sessionRecord, bool) {
+	var rec sessionRecord
+	if len(row) < 4 {
+		return rec, false
+	}
+	t, err := time.Parse(time.RFC3339, row[0])
+	if err != nil {
+		return rec, false
+	}
+	rec.Time = t
+	rec.Overtime = row[1] == "overtime"
+	rec.Session, _ = strconv.Atoi(row[2])
+	if rec.Duration, err = strconv.Atoi(row[3]); err != nil {
+		return rec, false
+	}
+	col := func(i int) string {
+		if i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	rec.Interruptions, _ = strconv.Atoi(col(4))
+	rec.Task = col(5)
+	rec.Paused, _ = strconv.Atoi(col(6))
+	rec.Skipped, _ = strconv.ParseBool(col(7))
+	return rec, true
+}
+
+// appendCSV adds rec to the CSV log at path, starting a new file with the
+// header.
+func appendCSV(path string, rec sessionRecord) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if info.Size() == 0 {
+		_ = w.Write(csvHeader)
+	}
+	_ = w.Write(csvRow(rec))
+	w.Flush()
+	return w.Error()
+}
+
+// readCSV loads the records of a CSV log, skipping the header and any rows
+// that don't parse.
+func readCSV(r io.Reader) ([]sessionRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	var recs []sessionRecord
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return recs, nil
+		}
+		if err != nil {
+			// A malformed row is skipped like a bad JSON line; anything
+			// else is a read failure.
+			if _, ok := err.(*csv.ParseError); ok {
+				continue
+			}
+			return recs, err
+		}
+		if rec, ok := parseCSVRow(row); ok {
+			recs = append(recs, rec)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// daylight decides when -night-theme takes over from -theme: at fixed
+// times of day, or at sunset and sunrise for a -location.
+type daylight struct {
+	dayAt, nightAt time.Duration // from midnight
+	located        bool
+	lat, lon       float64 // degrees, north and east positive
+}
+
+// isNight reports whether the night theme applies at now.
+func (d daylight) isNight(now time.Time) bool {
+	if d.located {
+		rise, set, up := sunTimes(now, d.lat, d.lon)
+		if rise.IsZero() {
+			// The sun doesn't cross the horizon today.
+			return !up
+		}
+		return now.Before(rise) || !now.Before(set)
+	}
+	tod := now.Sub(startOfDay(now))
+	if d.dayAt <= d.nightAt {
+		return tod < d.dayAt || tod >= d.nightAt
+	}
+	// A day that runs past midnight, e.g. -day-at 18:00 -night-at 06:00.
+	return tod < d.dayAt && tod >= d.nightAt
+}
+
+// parseTimeOfDay parses "HH:MM" into the time since midnight.
+func parseTimeOfDay(s string) (time.Duration, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// parseLocation parses "LAT,LON" in decimal degrees, e.g. "52.52,13.40".
+func parseLocation(s string) (lat, lon float64, err error) {
+	a, b, ok := strings.Cut(s, ",")
+	if ok {
+		lat, err = strconv.ParseFloat(strings.TrimSpace(a), 64)
+	}
+	if ok && err == nil {
+		lon, err = strconv.ParseFloat(strings.TrimSpace(b), 64)
+	}
+	if !ok || err != nil || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		return 0, 0, fmt.Errorf("invalid -location %q: want LAT,LON in degrees, e.g. 52.52,13.40", s)
+	}
+	return lat, lon, nil
+}
+
+// sunTimes returns sunrise and sunset on the local day of now, using the
+// sunrise equation; it's good to a minute or two, which is plenty for a
+// palette. When the sun stays up or down all day the times are zero and
+// up says which.
+func sunTimes(now time.Time, lat, lon float64) (rise, set time.Time, up bool) {
+	const rad = math.Pi / 180
+	noon := startOfDay(now).Add(12 * time.Hour)
+	jd := float64(noon.Unix())/86400 + 2440587.5
+	n := math.Ceil(jd - 2451545 + 0.0008)
+	mean := n - lon/360 // mean solar noon
+	anomaly := math.Mod(357.5291+0.98560028*mean, 360)
+	center := 1.9148*math.Sin(anomaly*rad) + 0.02*math.Sin(2*anomaly*rad) + 0.0003*math.Sin(3*anomaly*rad)
+	ecliptic := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := 2451545 + mean + 0.0053*math.Sin(anomaly*rad) - 0.0069*math.Sin(2*ecliptic*rad)
+	decl := math.Asin(math.Sin(ecliptic*rad) * math.Sin(23.4397*rad))
+	cosHour := (math.Sin(-0.833*rad) - math.Sin(lat*rad)*math.Sin(decl)) / (math.Cos(lat*rad) * math.Cos(decl))
+	if cosHour < -1 || cosHour > 1 {
+		return time.Time{}, time.Time{}, cosHour < -1
+	}
+	hour := math.Acos(cosHour) / rad / 360 // half the day, in days
+	toTime := func(jd float64) time.Time {
+		return time.Unix(0, int64((jd-2440587.5)*86400*float64(time.Second))).Local()
+	}
+	return toTime(transit - hour), toTime(transit + hour), true
+}
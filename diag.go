@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/gen2brain/beeep"
+)
+
+// runNotificationTest fires the alert sound and a desktop notification once
+// each, reporting what it tried. It returns false if either failed.
+func runNotificationTest(w io.Writer, sound alertSound, iconPath string) bool {
+	ok := true
+	fmt.Fprintf(w, "OS:           %s/%s\n", runtime.GOOS, runtime.GOARCH)
+
+	fmt.Fprintln(w, "Sound:        playing...")
+	used, err := playAlertSync(sound)
+	if err != nil {
+		ok = false
+		fmt.Fprintf(w, "              FAILED via %s: %v\n", used, err)
+	} else {
+		fmt.Fprintf(w, "              ok via %s\n", used)
+	}
+
+	icon := iconPath
+	if icon == "" {
+		icon = "(none)"
+	}
+	fmt.Fprintf(w, "Notification: sending (icon %s)...\n", icon)
+	if err := beeep.Notify("Pomodoro test 🍅", "If you can see this, notifications work.", iconPath); err != nil {
+		ok = false
+		fmt.Fprintf(w, "              FAILED: %v\n", err)
+	} else {
+		fmt.Fprintln(w, "              ok")
+	}
+	return ok
+}
+
+// doctorTool is an outside program that alerts go through on some
+// platform.
+type doctorTool struct {
+	name   string
+	use    string
+	advice string // what to do when it's missing
+	alt    string // another tool that does the same job, if any
+}
+
+// doctorTools lists the programs that matter on goos, in the order
+// they're tried.
+func doctorTools(goos string) []doctorTool {
+	switch goos {
+	case "windows":
+		return []doctorTool{
+			{"powershell", "sound files", "PowerShell ships with Windows; check that it's on PATH", ""},
+		}
+	case "darwin":
+		return []doctorTool{
+			{"terminal-notifier", "notifications", "brew install terminal-notifier", "osascript"},
+			{"osascript", "notifications and beeps", "osascript ships with macOS; check that /usr/bin is on PATH", ""},
+			{"afplay", "sound files", "afplay ships with macOS; check that /usr/bin is on PATH", ""},
+		}
+	}
+	return []doctorTool{
+		{"notify-send", "notifications without D-Bus", "install libnotify (libnotify-bin on Debian and Ubuntu)", ""},
+		{"paplay", "sound files", "install pulseaudio-utils, or pipewire-pulse on PipeWire systems", "aplay"},
+		{"aplay", "sound files, if paplay is missing", "install alsa-utils", "paplay"},
+	}
+}
+
+// runDoctor checks what alerts depend on and says how to fix what's
+// missing. Unlike runNotificationTest it plays no sound, though it does
+// send one notification. It returns false if notifications don't work.
+func runDoctor(w io.Writer, sound alertSound, iconPath string) bool {
+	var advice []string
+	fmt.Fprintf(w, "OS:           %s/%s\n", runtime.GOOS, runtime.GOARCH)
+
+	fmt.Fprintln(w, "Tools:")
+	missing, advised := map[string]bool{}, map[string]bool{}
+	tools := doctorTools(runtime.GOOS)
+	for _, t := range tools {
+		if path, err := exec.LookPath(t.name); err == nil {
+			fmt.Fprintf(w, "  %-18s found at %s\n", t.name, path)
+		} else {
+			fmt.Fprintf(w, "  %-18s missing (used for %s)\n", t.name, t.use)
+			missing[t.name] = true
+		}
+	}
+	for _, t := range tools {
+		// Of a pair that do the same job, one is enough, and only the
+		// first is worth suggesting.
+		if !missing[t.name] || t.alt != "" && (!missing[t.alt] || advised[t.alt]) {
+			continue
+		}
+		advised[t.name] = true
+		advice = append(advice, t.name+": "+t.advice)
+	}
+
+	switch {
+	case sound.path != "":
+		if _, err := os.Stat(sound.path); err != nil {
+			fmt.Fprintf(w, "Sound:        %v\n", err)
+			advice = append(advice, "-sound: point it at a file that exists")
+		} else if soundCommand(sound) == nil {
+			fmt.Fprintf(w, "Sound:        no player for %s; pomo will beep instead\n", sound.path)
+			advice = append(advice, "-sound: install one of the players above")
+		} else {
+			fmt.Fprintf(w, "Sound:        %s\n", sound.path)
+		}
+	case len(sound.tones) > 0:
+		fmt.Fprintln(w, "Sound:        sound theme (beeps)")
+	case soundCommand(sound) != nil:
+		fmt.Fprintln(w, "Sound:        platform default")
+	default:
+		fmt.Fprintln(w, "Sound:        beep")
+		if runtime.GOOS == "linux" {
+			advice = append(advice, "beeps need the pcspkr module or a terminal that rings its bell; -sound FILE is more reliable, and -bell rings the terminal itself")
+		}
+	}
+
+	fmt.Fprintln(w, "Notification: sending...")
+	err := beeep.Notify("Pomodoro doctor 🍅", "If you can see this, notifications work.", iconPath)
+	if err != nil {
+		fmt.Fprintf(w, "              FAILED: %v\n", err)
+		switch runtime.GOOS {
+		case "windows":
+			advice = append(advice, "notifications: check that they're allowed in Settings > System > Notifications")
+		case "darwin":
+			advice = append(advice, "notifications: allow them for your terminal in System Settings > Notifications")
+		default:
+			advice = append(advice, "notifications: start a notification daemon (your desktop's, dunst or mako) and check DBUS_SESSION_BUS_ADDRESS is set")
+		}
+	} else {
+		fmt.Fprintln(w, "              ok (check that it appeared)")
+	}
+
+	if len(advice) > 0 {
+		fmt.Fprintln(w, "\nAdvice:")
+		for _, a := range advice {
+			fmt.Fprintf(w, "  - %s\n", a)
+		}
+	}
+	return err == nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// jsonEvent is one line of -json output.
+type jsonEvent struct {
+	Event    string    `json:"event"` // start, tick, finish, complete or cancelled
+	Time     time.Time `json:"time"`
+	Phase    string    `json:"phase"`
+	Session  int       `json:"session"`
+	Total    int       `json:"total"`
+	TimeLeft int       `json:"time_left_seconds"`
+}
+
+// phaseKey identifies the phase the timer is in; a change means one phase
+// finished and another started.
+type phaseKey struct {
+	phase       string
+	session     int
+	cycle       int
+	interjected bool
+}
+
+func (m model) phaseKey() phaseKey {
+	return phaseKey{m.phaseName(), m.currentSession, m.cycle, m.interjected}
+}
+
+// runJSON drives the timer without the TUI, writing newline-delimited JSON
+// events to w. Phases always start automatically, and never pause for
+// idleness, since there is nobody at the keyboard. Tick events are
+// written every tickEvery, or never if it's 0.
+func runJSON(m model, w io.Writer, tickEvery time.Duration) {
+	m.opts.autoStartWork = true
+	m.opts.autoStartBreak = true
+	m.opts.persistAlarm = false
+	m.opts.idleAfter = 0
+	if m.state == stateSetup {
+		m, _ = m.startTimer()
+	}
+	if m.paused {
+		m.paused = false
+		m.waitingToStart = false
+		m.resumeClock()
+	}
+
+	enc := json.NewEncoder(w)
+	emit := func(event string, m model) {
+		snap := m.snapshot()
+		switch event {
+		case "finish":
+			snap.TimeLeft = 0
+		case "complete":
+			snap.Phase, snap.Session, snap.TimeLeft = "done", m.sessionsDone, 0
+		}
+		_ = enc.Encode(jsonEvent{
+			Event:    event,
+			Time:     time.Now(),
+			Phase:    snap.Phase,
+			Session:  snap.Session,
+			Total:    snap.Total,
+			TimeLeft: snap.TimeLeft,
+		})
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	// Pause and skip signals are for the TUI.
+	ignoreSignals()
+
+	// Like tea.Tick, each tick is scheduled a full second after the last one
+	// was handled, so it never lands just before a phase's deadline.
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
+
+	emit("start", m)
+	lastTick := time.Now()
+	for {
+		select {
+		case <-sig:
+			emit("cancelled", m)
+			return
+		case now := <-timer.C:
+			next, _ := m.Update(tickMsg{id: m.timerID, at: now})
+			timer.Reset(time.Second)
+			prev := m
+			m = next.(model)
+			if m.completed {
+				emit("finish", prev)
+				emit("complete", m)
+				return
+			}
+			if m.phaseKey() != prev.phaseKey() {
+				emit("finish", prev)
+				emit("start", m)
+				lastTick = now
+				continue
+			}
+			if tickEvery > 0 && now.Sub(lastTick).Round(time.Second) >= tickEvery {
+				emit("tick", m)
+				lastTick = now
+			}
+		}
+	}
+}
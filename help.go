@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// keyHelp is one row of the help overlay.
+type keyHelp struct {
+	keys string
+	desc string
+}
+
+// helpBindings lists the keys that work on the current screen right now.
+func (m model) helpBindings() []keyHelp {
+	if m.state == stateSetup {
+		return []keyHelp{
+			{"TAB", "Next field (SHIFT+TAB goes back)"},
+			{"↑ / ↓", "Step a number by 1 (SHIFT: 5); on other fields, move"},
+			{"ENTER", "Next field; on the last one, start"},
+			{"CTRL+R", "Toggle repeat forever"},
+			{"F1", "Close this help"},
+			{"q", "Quit (outside the Task field; CTRL+C quits anywhere)"},
+		}
+	}
+	if m.countUp {
+		return []keyHelp{
+			{"SPACE", "Pause / resume"},
+			{"s", "Save this session and restart at 0"},
+			{"m", "Cycle mute mode"},
+			{"r", "Reset to the setup screen"},
+			{"?", "Close this help"},
+			{"q", "Quit (asks first; CTRL+C quits at once)"},
+		}
+	}
+	return []keyHelp{
+		{"SPACE", "Pause / resume"},
+		{"s", "Skip to the next phase"},
+		{"b", "Back to the previous session"},
+		{"p", "Unscheduled break, then resume this session"},
+		{"TAB", "Switch between work and break, same session"},
+		{"z", "Snooze a break that just ended"},
+		{"o", "Overtime: a little more work before the break that just began"},
+		{"↑ / ↓", "Add / remove a minute"},
+		{"1–9", "Add that many minutes (SHIFT takes them away)"},
+		{"+ / -", "Change the length of later phases of this type"},
+		{"i", "Note an interruption in this work session"},
+		{"t", "Rename the current task"},
+		{"e", "Show / hide elapsed time"},
+		{"f", "Focus mode: show only the clock"},
+		{"m", "Cycle mute mode: off, quiet until break, muted"},
+		{"R", "Restart the current phase from the top"},
+		{"r", "Reset to the setup screen"},
+		{"? / F1", "Close this help"},
+		{"q", "Quit (asks first; CTRL+C quits at once)"},
+	}
+}
+
+// timerKeys are the keys that act on the timer; they keep working while
+// the help overlay is open instead of closing it.
+var timerKeys = map[string]bool{
+	" ": true, "s": true, "b": true, "p": true, "z": true, "o": true, "m": true, "e": true, "f": true, "i": true, "R": true, "tab": true,
+	"up": true, "down": true, "shift+up": true, "shift+down": true,
+	"+": true, "=": true, "-": true,
+	"1": true, "2": true, "3": true, "4": true, "5": true, "6": true, "7": true, "8": true, "9": true,
+	"!": true, "@": true, "#": true, "$": true, "%": true, "^": true, "&": true, "*": true, "(": true,
+}
+
+// viewHelp is the full key reference shown by "?", or F1 on the setup
+// screen, where "?" is typed into the field.
+func (m model) viewHelp() string {
+	keyStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.accent)
+	descStyle := lipgloss.NewStyle().Foreground(m.theme.subtle)
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(m.theme.accent).Render("KEYS") + "\n\n")
+	for _, h := range m.helpBindings() {
+		b.WriteString(keyStyle.Width(8).Render(h.keys) + descStyle.Render(h.desc) + "\n")
+	}
+	if m.state == stateSetup {
+		b.WriteString("\n" + descStyle.Render("Durations take 25, 90s, 1h30m or 2x25. Sessions take a\ncount, or a time budget such as 2h.\n\n'pomo 25 5 4' skips this screen; add --edit to fill it in\ninstead. --preset, --task and --repeat work here too;\nsee 'pomo -h' for the full list."))
+	} else {
+		b.WriteString("\n" + descStyle.Render("Flags such as --goal, --schedule and --mute-until-break\nchange how the timer runs; see 'pomo -h' for the full list."))
+	}
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.subtle).
+		Padding(1, 3).
+		Render(b.String())
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// sessionRecord is one line of the history log.
+type sessionRecord struct {
+	Time          time.Time `json:"time"`
+	Duration      int       `json:"duration_seconds"`
+	Paused        int       `json:"paused_seconds"`
+	Session       int       `json:"session"`
+	Skipped       bool      `json:"skipped"`
+	Task          string    `json:"task,omitempty"`
+	Interruptions int       `json:"interruptions,omitempty"`
+	// Overtime marks extra work on the session before it, which isn't a
+	// pomodoro of its own.
+	Overtime bool `json:"overtime,omitempty"`
+}
+
+// dataDir returns where pomodoro keeps its files: $XDG_DATA_HOME/pomodoro
+// when set, otherwise ~/.pomodoro.
+func dataDir() (string, error) {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "pomodoro"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".pomodoro"), nil
+}
+
+// historyPath is where the history log in format ("jsonl" or "csv")
+// lives.
+func historyPath(format string) (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history."+format), nil
+}
+
+// logSession appends rec to the history log in format, "jsonl" or "csv".
+// Failures are ignored so a read-only disk never takes down the timer.
+func logSession(format string, rec sessionRecord) {
+	if format != "csv" {
+		format = "jsonl"
+	}
+	path, err := historyPath(format)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	if format == "csv" {
+		_ = appendCSV(path, rec)
+		return
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.Write(append(line, '\n'))
+}
+
+// readHistory loads every record from both history logs, so switching
+// -log-format keeps the old sessions. It fails with fs.ErrNotExist only
+// when there's neither.
+func readHistory() ([]sessionRecord, error) {
+	var recs []sessionRecord
+	found := false
+	var firstErr error
+	for _, format := range []string{"jsonl", "csv"} {
+		path, err := historyPath(format)
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		var more []sessionRecord
+		if format == "csv" {
+			more, err = readCSV(f)
+		} else {
+			more, err = readJSONL(f)
+		}
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		recs = append(recs, more...)
+	}
+	if !found {
+		return nil, firstErr
+	}
+	slices.SortStableFunc(recs, func(a, b sessionRecord) int { return a.Time.Compare(b.Time) })
+	return recs, nil
+}
+
+// readJSONL loads the records of a JSON-lines log, skipping lines that
+// don't parse.
+func readJSONL(r io.Reader) ([]sessionRecord, error) {
+	var recs []sessionRecord
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		var rec sessionRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	return recs, sc.Err()
+}
+
+// startOfDay returns local midnight of the day t falls on.
+func startOfDay(t time.Time) time.Time {
+	y, mo, d := t.Local().Date()
+	return time.Date(y, mo, d, 0, 0, 0, 0, time.Local)
+}
+
+// completedOn counts the pomodoros finished (not skipped) on the local
+// calendar day that starts at day.
+func completedOn(day time.Time) int {
+	recs, _ := readHistory()
+	n := 0
+	for _, rec := range recs {
+		if !rec.Skipped && !rec.Overtime && startOfDay(rec.Time).Equal(day) {
+			n++
+		}
+	}
+	return n
+}
+
+// streaks finds the current and the longest run of consecutive local days
+// with at least one completed pomodoro. A run that reached yesterday is
+// still current, since today isn't over; today reports whether it already
+// has one.
+func streaks(recs []sessionRecord, now time.Time) (current, best int, today bool) {
+	days := make(map[time.Time]bool)
+	for _, rec := range recs {
+		if !rec.Skipped && !rec.Overtime {
+			days[startOfDay(rec.Time)] = true
+		}
+	}
+	run := 0
+	sorted := slices.SortedFunc(maps.Keys(days), time.Time.Compare)
+	for i, day := range sorted {
+		// AddDate rather than 24h, so a DST change doesn't break a run.
+		if i > 0 && sorted[i-1].AddDate(0, 0, 1).Equal(day) {
+			run++
+		} else {
+			run = 1
+		}
+		best = max(best, run)
+	}
+	day := startOfDay(now)
+	today = days[day]
+	if !today {
+		day = day.AddDate(0, 0, -1)
+	}
+	for days[day] {
+		current++
+		day = day.AddDate(0, 0, -1)
+	}
+	return current, best, today
+}
+
+// streakLine renders a streak, e.g. "🔥 5-day streak (best: 12)".
+func streakLine(current, best int) string {
+	return fmt.Sprintf("🔥 %d-day streak (best: %d)", current, best)
+}
+
+// runStreak prints the current and longest daily streaks.
+func runStreak(w io.Writer) error {
+	recs, err := readHistory()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	current, best, today := streaks(recs, time.Now())
+	switch {
+	case best == 0:
+		fmt.Fprintln(w, "No streak yet: finish a pomodoro to start one")
+	case current == 0:
+		fmt.Fprintf(w, "No current streak (best: %d). Finish a pomodoro today to start again\n", best)
+	default:
+		fmt.Fprintln(w, streakLine(current, best))
+		if !today {
+			fmt.Fprintln(w, "Nothing yet today: finish a pomodoro to keep it going")
+		}
+	}
+	return nil
+}
+
+// runStats prints completed pomodoro totals and a seven-day breakdown.
+func runStats(w io.Writer) error {
+	recs, err := readHistory()
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && len(recs) == 0) {
+		fmt.Fprintln(w, "No history yet")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	today := startOfDay(time.Now())
+	// Weeks start on Monday.
+	week := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+
+	var todayN, weekN, allN int
+	var focused, paused time.Duration
+	perDay := make(map[time.Time]int)
+	for _, rec := range recs {
+		focused += time.Duration(rec.Duration) * time.Second
+		paused += time.Duration(rec.Paused) * time.Second
+		if rec.Skipped || rec.Overtime {
+			continue
+		}
+		day := startOfDay(rec.Time)
+		allN++
+		perDay[day]++
+		if !day.Before(week) {
+			weekN++
+		}
+		if day.Equal(today) {
+			todayN++
+		}
+	}
+
+	fmt.Fprintf(w, "Today:     %d\n", todayN)
+	fmt.Fprintf(w, "This week: %d\n", weekN)
+	fmt.Fprintf(w, "All time:  %d\n", allN)
+	fmt.Fprintf(w, "Focused:   %d min\n", int(focused.Minutes()))
+	fmt.Fprintf(w, "Paused:    %d min\n", int(paused.Minutes()))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Last 7 days:")
+	for i := 6; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		fmt.Fprintf(w, "  %s  %d\n", day.Format("Mon 2006-01-02"), perDay[day])
+	}
+	return nil
+}
+
+// parseDay reads a day for 'pomo history': YYYY-MM-DD, "today" or
+// "yesterday", as local midnight.
+func parseDay(s string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(s) {
+	case "", "today":
+		return startOfDay(now), nil
+	case "yesterday":
+		return startOfDay(now).AddDate(0, 0, -1), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: want YYYY-MM-DD, today or yesterday", s)
+	}
+	return t, nil
+}
+
+// runHistory prints every session logged on the given day.
+func runHistory(w io.Writer, arg string, clock12 bool) error {
+	day, err := parseDay(arg, time.Now())
+	if err != nil {
+		return err
+	}
+	recs, err := readHistory()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	var matched []sessionRecord
+	for _, rec := range recs {
+		if startOfDay(rec.Time).Equal(day) {
+			matched = append(matched, rec)
+		}
+	}
+	label := day.Format("Mon 2006-01-02")
+	if len(matched) == 0 {
+		fmt.Fprintf(w, "No sessions on %s\n", label)
+		return nil
+	}
+
+	fmt.Fprintf(w, "Sessions on %s:\n\n", label)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "START\tFOCUSED\tPAUSED\tINTERRUPTIONS\tTASK")
+	var focused time.Duration
+	skipped := 0
+	for _, rec := range matched {
+		// Records are written when a session ends.
+		dur := time.Duration(rec.Duration) * time.Second
+		paused := time.Duration(rec.Paused) * time.Second
+		start := rec.Time.Local().Add(-dur - paused)
+		task := rec.Task
+		if rec.Overtime {
+			task = strings.TrimSpace(task + " (overtime)")
+		}
+		if rec.Skipped {
+			task = strings.TrimSpace(task + " (skipped)")
+			skipped++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", formatClock(start, clock12), formatDuration(dur), formatDuration(paused), rec.Interruptions, task)
+		focused += dur
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if skipped > 0 {
+		fmt.Fprintf(w, "\n%d sessions (%d completed, %d skipped), %s focused\n", len(matched), len(matched)-skipped, skipped, formatDuration(focused))
+	} else {
+		fmt.Fprintf(w, "\n%d sessions, %s focused\n", len(matched), formatDuration(focused))
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// runHook runs command through the platform shell in the background, with
+// env added to the environment. Its output is discarded and failures are
+// ignored, so a broken hook never holds up the timer.
+func runHook(command string, env []string) {
+	if strings.TrimSpace(command) == "" {
+		return
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), env...)
+	go func() { _ = cmd.Run() }()
+}
+
+// runPhaseHook runs the -on-work or -on-break command for the phase that
+// is about to begin.
+func (m model) runPhaseHook() {
+	command := m.opts.onWork
+	if m.timerType == typeBreak {
+		command = m.opts.onBreak
+	}
+	runHook(command, []string{
+		"POMO_PHASE=" + m.phaseName(),
+		"POMO_SESSION=" + strconv.Itoa(m.currentSession),
+		"POMO_TOTAL=" + strconv.Itoa(m.sessionsTotal),
+		"POMO_DURATION_SECONDS=" + strconv.Itoa(int(m.currentPhaseDuration.Seconds())),
+		"POMO_TASK=" + m.task,
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	_ "embed"
+	"os"
+	"path/filepath"
+)
+
+//go:embed assets/tomato.png
+var tomatoIcon []byte
+
+// resolveIcon finds the notification icon. An explicit path is used as-is;
+// otherwise we look for assets/tomato.png next to the binary, then fall back
+// to a copy of the embedded icon in the data directory. It returns "" when
+// no usable file exists so notifications still go out, just without an icon.
+func resolveIcon(explicit string) string {
+	if explicit != "" {
+		if isFile(explicit) {
+			return explicit
+		}
+		return ""
+	}
+	if exe, err := os.Executable(); err == nil {
+		if p := filepath.Join(filepath.Dir(exe), "assets", "tomato.png"); isFile(p) {
+			return p
+		}
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return ""
+	}
+	p := filepath.Join(dir, "tomato.png")
+	if isFile(p) {
+		return p
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ""
+	}
+	if err := os.WriteFile(p, tomatoIcon, 0o644); err != nil {
+		return ""
+	}
+	return p
+}
+
+func isFile(p string) bool {
+	fi, err := os.Stat(p)
+	return err == nil && fi.Mode().IsRegular()
+}
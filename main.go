@@ -1,18 +1,23 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"os/exec"
+	"io/fs"
+	"math"
+	"math/rand/v2"
+	"os"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/gen2brain/beeep"
+	"github.com/charmbracelet/x/term"
 )
 
 // --- PIXEL BLOCK FONT ---
@@ -30,32 +35,193 @@ var bigDigits = map[rune][]string{
 	':': {"      ", "  ██  ", "      ", "  ██  ", "      "},
 }
 
+// thinDigits is a lighter three-row font for small terminals.
+var thinDigits = map[rune][]string{
+	'0': {"┌─┐", "│ │", "└─┘"},
+	'1': {"  ╷", "  │", "  ╵"},
+	'2': {"╶─┐", "┌─┘", "└─╴"},
+	'3': {"╶─┐", " ─┤", "╶─┘"},
+	'4': {"╷ ╷", "└─┤", "  ╵"},
+	'5': {"┌─╴", "└─┐", "╶─┘"},
+	'6': {"┌─╴", "├─┐", "└─┘"},
+	'7': {"╶─┐", "  │", "  ╵"},
+	'8': {"┌─┐", "├─┤", "└─┘"},
+	'9': {"┌─┐", "└─┤", "╶─┘"},
+	':': {" ", ":", " "},
+}
+
+// font is a digit set for the big clock. Every glyph in a font has height
+// rows, and each row of a glyph is the same width.
+type font struct {
+	glyphs map[rune][]string
+	height int
+}
+
+var fonts = map[string]font{
+	"block": {bigDigits, 5},
+	"thin":  {thinDigits, 3},
+}
+
+// fontByName looks up a font, falling back to block for unknown names.
+func fontByName(name string) font {
+	if f, ok := fonts[strings.ToLower(name)]; ok {
+		return f
+	}
+	return fonts["block"]
+}
+
 // --- Styles ---
 var (
-	colorBlue   = lipgloss.Color("33")
-	colorYellow = lipgloss.Color("220")
-	colorSubtle = lipgloss.Color("241")
-
 	styleContainer = lipgloss.NewStyle().Align(lipgloss.Center, lipgloss.Center)
-	styleInput     = lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).BorderForeground(colorSubtle).Padding(1, 3).Width(40)
-	styleHelp      = lipgloss.NewStyle().Foreground(colorSubtle).MarginTop(3)
+	styleInput     = lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(1, 3).Width(40)
+	styleHelp      = lipgloss.NewStyle().MarginTop(3)
+	styleError     = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
 )
 
+// theme is the palette used by every view.
+type theme struct {
+	work      lipgloss.TerminalColor // work phase and setup accents
+	brk       lipgloss.TerminalColor
+	longBreak lipgloss.TerminalColor
+	subtle    lipgloss.TerminalColor
+	accent    lipgloss.TerminalColor // setup and help highlights; work if unset
+}
+
+var themes = map[string]theme{
+	"default": {
+		work:      lipgloss.Color("33"),
+		brk:       lipgloss.Color("220"),
+		longBreak: lipgloss.Color("42"),
+		subtle:    lipgloss.Color("241"),
+	},
+	// Grayscale only, adapted to the terminal background so it stays readable.
+	"mono": {
+		work:      lipgloss.AdaptiveColor{Light: "232", Dark: "255"},
+		brk:       lipgloss.AdaptiveColor{Light: "238", Dark: "250"},
+		longBreak: lipgloss.AdaptiveColor{Light: "242", Dark: "246"},
+		subtle:    lipgloss.AdaptiveColor{Light: "248", Dark: "240"},
+	},
+	"solarized": {
+		work:      lipgloss.Color("#268bd2"),
+		brk:       lipgloss.Color("#b58900"),
+		longBreak: lipgloss.Color("#859900"),
+		subtle:    lipgloss.Color("#586e75"),
+	},
+	"dracula": {
+		work:      lipgloss.Color("#bd93f9"),
+		brk:       lipgloss.Color("#f1fa8c"),
+		longBreak: lipgloss.Color("#50fa7b"),
+		subtle:    lipgloss.Color("#6272a4"),
+	},
+}
+
+// themeByName looks up a theme, falling back to the default for unknown names.
+func themeByName(name string) theme {
+	t, ok := themes[strings.ToLower(name)]
+	if !ok {
+		t = themes["default"]
+	}
+	if t.accent == nil {
+		t.accent = t.work
+	}
+	return t
+}
+
+// withColors overrides t's work, break and accent colors with any of the
+// -work-color, -break-color and -accent-color values that are set.
+func (t theme) withColors(work, brk, accent string) theme {
+	if work != "" {
+		t.work = lipgloss.Color(work)
+	}
+	if brk != "" {
+		t.brk = lipgloss.Color(brk)
+	}
+	if accent != "" {
+		t.accent = lipgloss.Color(accent)
+	}
+	return t
+}
+
+// themeAt is the palette for now: the night theme after dark, when one
+// is set, and the day theme otherwise.
+func (m model) themeAt(now time.Time) theme {
+	if m.opts.nightTheme != "" && m.opts.daylight.isNight(now) {
+		return m.nightTheme
+	}
+	return m.dayTheme
+}
+
+// validColor reports whether s is a color lipgloss understands: an ANSI
+// index from 0 to 255, or hex as #rgb or #rrggbb.
+func validColor(s string) bool {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n >= 0 && n <= 255
+	}
+	hex, ok := strings.CutPrefix(s, "#")
+	if !ok || (len(hex) != 3 && len(hex) != 6) {
+		return false
+	}
+	_, err := strconv.ParseUint(hex, 16, 32)
+	return err == nil
+}
+
+// breakTips are suggestions shown during breaks, one picked per break.
+var breakTips = []string{
+	"Look 20 feet away for 20 seconds.",
+	"Stand up and stretch.",
+	"Drink a glass of water.",
+	"Roll your shoulders and neck.",
+	"Take a short walk.",
+	"Take five slow, deep breaths.",
+	"Rest your eyes: close them for a moment.",
+}
+
 // --- Model State ---
 type sessionState int
 
 const (
 	stateSetup sessionState = iota
 	stateRunning
+	// stateDone holds the summary on screen after the run, for
+	// -on-complete stay, until a key is pressed.
+	stateDone
 )
 
+// phase is one step of a -schedule: a work session and the break after it.
+type phase struct {
+	work time.Duration
+	brk  time.Duration
+}
+
 type timerType int
 
 const (
 	typeWork timerType = iota
 	typeBreak
+	// typeWarmup is the -warmup countdown before the first work session.
+	// It isn't a session: nothing is logged or counted for it.
+	typeWarmup
+)
+
+// muteMode decides which phase changes make a sound or a notification.
+type muteMode int
+
+const (
+	muteOff  muteMode = iota
+	muteWork          // nothing until the work session ends; breaks still end loudly
+	muteAll           // no sounds at all; notifications still fire
 )
 
+func (mm muteMode) String() string {
+	switch mm {
+	case muteWork:
+		return "QUIET UNTIL BREAK"
+	case muteAll:
+		return "MUTED"
+	}
+	return ""
+}
+
 type model struct {
 	width  int
 	height int
@@ -64,62 +230,354 @@ type model struct {
 	timerType timerType
 	paused    bool
 
+	// waitingToStart is set when a phase is held for the user to start it.
+	waitingToStart bool
+	// awaitingAck is set while a persistent alarm repeats after a phase ends;
+	// alarmFor is the type of the phase that ended, for the mute rules.
+	awaitingAck bool
+	alarmFor    timerType
+
 	inputs     []textinput.Model
 	focusIndex int
+	wasReset   bool
+
+	// setupError explains why the setup inputs were rejected; it is shown
+	// under input setupErrorField.
+	setupError      string
+	setupErrorField int
+
+	workDuration      time.Duration
+	breakDuration     time.Duration
+	longBreakDuration time.Duration
+	longBreakEvery    int
+	longBreak         bool
+	timeLeft          time.Duration
+	phaseElapsed      time.Duration
+
+	// breakRatio, when set, sizes each short break as work/breakRatio
+	// instead of using breakDuration.
+	breakRatio float64
+
+	// schedule, when set, gives each work session its own work and break
+	// length in place of workDuration and breakDuration.
+	schedule []phase
+
+	// The countdown runs against the wall clock: timeLeft is recomputed
+	// from endTime on every tick so pauses and slow ticks can't cause drift.
+	endTime  time.Time
+	pausedAt time.Time
+	lastTick time.Time
+	// lastPip is the countdown second that last pipped.
+	lastPip time.Duration
+	// pausedAccumulated is how long the current phase has spent paused.
+	pausedAccumulated time.Duration
+	// pauses counts SPACE pauses in the current work phase, for -max-pauses.
+	pauses int
+	// interruptions counts "i" presses during the current work session.
+	interruptions int
 
-	workDuration  time.Duration
-	breakDuration time.Duration
-	timeLeft      time.Duration
+	// Stopwatch mode counts up from countStart with no automatic finish.
+	countUp     bool
+	countStart  time.Time
+	timeElapsed time.Duration
+
+	currentPhaseDuration time.Duration
 
 	sessionsTotal  int
 	currentSession int
+	repeat         bool
+	cycle          int // completed passes through all sessions in repeat mode
+
+	// budget, when set, is the focused time the run aims for instead of a
+	// session count; sessionsTotal is then re-planned after each session.
+	// budgetBase is focusedTotal when the run began.
+	budget     time.Duration
+	budgetBase time.Duration
+
+	// Totals for the end-of-run summary.
+	focusedTotal time.Duration
+	breakTotal   time.Duration
+	sessionsDone int
+	// sessionsSkipped counts the work sessions -skip-no-count left out
+	// of sessionsDone and focusedTotal.
+	sessionsSkipped int
+	completed       bool
 
 	// <--- CHANGED: Added timerID to track unique timer loops
 	timerID int
+
+	opts  options
+	theme theme
+	font  font
+	mute  muteMode
+
+	// dayTheme and nightTheme are what theme switches between when
+	// -night-theme is set.
+	dayTheme, nightTheme theme
+
+	// clicks is where the last frame drew its buttons, for -mouse.
+	clicks *clickZones
+
+	// note is a short confirmation shown in the status line until noteUntil.
+	note      string
+	noteUntil time.Time
+
+	confirmingQuit bool
+
+	// showHelp replaces the timer with the full key reference.
+	showHelp bool
+
+	// breakTip is the suggestion shown during the current break.
+	breakTip string
+
+	// focusMode shows nothing but the clock.
+	focusMode bool
+
+	// showElapsed adds an "elapsed 12:34 / 25:00" line under the clock.
+	showElapsed bool
+
+	// lastActivity is the time of the last key press; idlePaused is set
+	// when the work timer paused itself after opts.idleAfter without one.
+	lastActivity time.Time
+	idlePaused   bool
+
+	// goalDone counts the pomodoros finished on goalDay, seeded from the
+	// history log, toward the daily goal in opts.goal.
+	goalDay      time.Time
+	goalDone     int
+	goalNotified bool
+
+	// streak is the current daily streak for the setup screen, worked out
+	// once at startup; "" when there's none.
+	streak string
+
+	// flashUntil briefly fills the screen with flashColor when a phase ends.
+	flashUntil time.Time
+	flashColor lipgloss.TerminalColor
+
+	task        string
+	editingTask bool
+	taskInput   textinput.Model
+
+	// An interjected break is an unscheduled break taken mid-session; the
+	// work clock is stashed and restored when it ends.
+	interjected          bool
+	stashedTimeLeft      time.Duration
+	stashedPhaseDuration time.Duration
+	stashedElapsed       time.Duration
+	stashedPaused        time.Duration
+	stashedPauses        int
+
+	// Snoozing stretches a break that just ended with a short interjected
+	// break; snoozes counts them so only opts.maxSnoozes are allowed.
+	snoozable bool
+	snoozing  bool
+	snoozes   int
+
+	// Overtime puts off the break that just began with a little more work
+	// on the same session; overtimes counts them so only opts.maxOvertimes
+	// run back to back. overtimeWork is the work planned so far this
+	// session, which a -ratio break is worked out from.
+	overtime     bool
+	overtimes    int
+	overtimeWork time.Duration
+
+	// Micro-breaks pause the work countdown without ending the phase.
+	inMicroBreak   bool
+	microLeft      time.Duration
+	microEnd       time.Time
+	workSinceMicro time.Duration
+}
+
+// Built-in defaults, used when neither an argument nor the config file
+// provides a value.
+const (
+	defaultWork      = 25 * time.Minute
+	defaultBreak     = 5 * time.Minute
+	defaultLongBreak = 15 * time.Minute
+	defaultSessions  = 4
+)
+
+// options holds settings that come from command-line flags and the config
+// file.
+type options struct {
+	// Used for empty arguments and setup fields.
+	work      time.Duration
+	brk       time.Duration
+	longBreak time.Duration
+	sessions  int
+
+	microBreak  time.Duration
+	microEvery  time.Duration
+	countdown   int // beep during the final N seconds before a boundary
+	warmup      time.Duration
+	longEvery   int
+	mute        muteMode
+	noLog       bool
+	logFormat   string // jsonl or csv
+	theme       string
+	nightTheme  string // swapped in by daylight; empty keeps theme all day
+	daylight    daylight
+	font        string
+	workColor   string
+	breakColor  string
+	accentColor string
+	stopwatch   bool
+	repeat      bool
+	startPaused bool
+	task        string
+	onComplete  string // quit, stay or loop
+	edit        bool   // open the setup screen filled in, even with arguments
+
+	autoStartWork  bool
+	autoStartBreak bool
+	persistAlarm   bool
+	notifier       Notifier
+	bell           *atomic.Bool // raised by the -bell notifier, rung by View
+	compact        bool
+	noTips         bool
+	clock12        bool
+	snooze         time.Duration
+	maxSnoozes     int
+	overtime       time.Duration
+	maxOvertimes   int
+	maxPauses      int // SPACE pauses allowed per work phase; 0 is no limit
+	tickEvery      time.Duration
+	onWork         string
+	onBreak        string
+	ratio          float64
+	goal           int
+	skipNoCount    bool // a skipped work session doesn't count as done
+	idleAfter      time.Duration
+	schedule       []phase
+	ring           bool
+	mouse          bool
+	inline         bool
+	// maxFocus ends the run once this much work is done; 0 means no limit.
+	maxFocus time.Duration
+	// until is the time of day, from midnight, after which no new work
+	// session starts; 0 means no cutoff.
+	until time.Duration
+
+	// canResume is set when an unfinished run can be picked up with
+	// 'pomo resume'; the setup screen mentions it.
+	canResume bool
+
+	// status, statusFile and stateFile, when set, are refreshed after
+	// every update.
+	status     *sharedStatus
+	statusFile *statusFile
+	stateFile  *stateFile
 }
 
 // --- Initialization ---
 
-func initialModel(workArg, breakArg, sessArg string) model {
+func initialModel(workArg, breakArg, sessArg, longArg string, opts options) model {
 	m := model{
-		inputs:  make([]textinput.Model, 3),
+		inputs:  make([]textinput.Model, 5),
 		timerID: 0, // <--- CHANGED: Initialize ID
+		opts:    opts,
+		theme:   themeByName(opts.theme).withColors(opts.workColor, opts.breakColor, opts.accentColor),
+		font:    fontByName(opts.font),
+		mute:    opts.mute,
+		countUp: opts.stopwatch,
+		repeat:  opts.repeat,
+
+		breakRatio:   opts.ratio,
+		schedule:     opts.schedule,
+		lastActivity: time.Now(),
+		clicks:       &clickZones{},
+	}
+	if m.opts.notifier == nil {
+		m.opts.notifier = SilentNotifier{}
+	}
+	m.dayTheme = m.theme
+	if opts.nightTheme != "" {
+		m.nightTheme = themeByName(opts.nightTheme).withColors(opts.workColor, opts.breakColor, opts.accentColor)
+		m.theme = m.themeAt(time.Now())
 	}
 
 	t0 := textinput.New()
 	t0.Placeholder = "Work (e.g. 25, 30s)"
 	t0.Focus()
+	t0.PromptStyle = lipgloss.NewStyle().Foreground(m.theme.accent)
 	t0.Width = 30
 	t1 := textinput.New()
 	t1.Placeholder = "Break (e.g. 5m)"
 	t1.Width = 30
 	t2 := textinput.New()
-	t2.Placeholder = "Sessions (e.g. 4)"
+	t2.Placeholder = "Sessions (e.g. 4 or 2h)"
 	t2.Width = 30
+	t3 := textinput.New()
+	t3.Placeholder = "Long break (e.g. 15m)"
+	t3.Width = 30
+	t4 := textinput.New()
+	t4.Placeholder = "Task (optional)"
+	t4.Width = 30
+	t4.SetValue(opts.task)
 
 	m.inputs[0] = t0
 	m.inputs[1] = t1
 	m.inputs[2] = t2
+	m.inputs[3] = t3
+	m.inputs[4] = t4
+	m.task = opts.task
+
+	m.longBreakEvery = opts.longEvery
+	if m.longBreakEvery <= 0 {
+		m.longBreakEvery = 4
+	}
+
+	if opts.goal > 0 {
+		m.goalDay = startOfDay(time.Now())
+		m.goalDone = completedOn(m.goalDay)
+		m.goalNotified = m.goalDone >= opts.goal
+	}
 
-	if workArg != "" {
+	if m.countUp {
+		m.state = stateRunning
+		m.currentSession = 1
+		m.restartStopwatch()
+		m.paused = opts.startPaused
+		m.waitingToStart = opts.startPaused
+		m.timerID++
+	} else if workArg != "" && !opts.edit {
 		m.state = stateRunning
 		m.timerType = typeWork
-		m.paused = false
+		m.paused = opts.startPaused
+		m.waitingToStart = opts.startPaused
 		m.currentSession = 1
-		m.workDuration = parseDurationInput(workArg, 25)
-		m.breakDuration = parseDurationInput(breakArg, 5)
-		s, _ := strconv.Atoi(sessArg)
+		m.workDuration = parseDurationInput(workArg, opts.work)
+		m.breakDuration = parseDurationInput(breakArg, opts.brk)
+		m.longBreakDuration = parseDurationInput(longArg, opts.longBreak)
+		s, budget, _ := parseSessions(sessArg)
+		if budget > 0 {
+			s = sessionsFor(budget, m.workDuration)
+		}
 		if s == 0 {
-			s = 4
+			s = opts.sessions
 		}
 		m.sessionsTotal = s
-		m.timeLeft = m.workDuration
+		m.budget = budget
+		m.setPhaseTime(m.workFor(1))
+		m.startWarmup()
+		m.pausedAt = time.Now()
 
 		// <--- CHANGED: Increment ID when starting immediately
 		m.timerID++
 	} else {
 		m.state = stateSetup
 		m.timerType = typeWork
+		if opts.edit {
+			m.prefillSetup(workArg, breakArg, sessArg, longArg)
+		}
+		recs, _ := readHistory()
+		if current, best, today := streaks(recs, time.Now()); current > 0 {
+			m.streak = streakLine(current, best)
+			if !today {
+				m.streak += " — one pomodoro today keeps it going"
+			}
+		}
 	}
 
 	return m
@@ -128,7 +586,7 @@ func initialModel(workArg, breakArg, sessArg string) model {
 func (m model) Init() tea.Cmd {
 	// <--- CHANGED: If quick start, ensure we start the tick loop with the ID
 	if m.state == stateRunning {
-		return tea.Batch(textinput.Blink, doTick(m.timerID))
+		return tea.Batch(textinput.Blink, doTick(m.timerID, m.opts.tickEvery))
 	}
 	return textinput.Blink
 }
@@ -138,16 +596,89 @@ func (m model) Init() tea.Cmd {
 // <--- CHANGED: tickMsg is now a struct containing the ID
 type tickMsg struct {
 	id int
+	at time.Time
 }
 
 // <--- CHANGED: doTick now accepts an ID
-func doTick(id int) tea.Cmd {
-	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
-		return tickMsg{id: id}
+// Ticks come every interval (a second unless -tick says otherwise); the
+// clock still only shows whole seconds.
+func doTick(id int, interval time.Duration) tea.Cmd {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return tea.Tick(interval, func(t time.Time) tea.Msg {
+		return tickMsg{id: id, at: t}
+	})
+}
+
+// alarmMsg repeats the end-of-phase alarm until the user acknowledges it.
+type alarmMsg struct {
+	id int
+}
+
+const alarmInterval = 5 * time.Second
+
+func doAlarm(id int) tea.Cmd {
+	return tea.Tick(alarmInterval, func(time.Time) tea.Msg {
+		return alarmMsg{id: id}
+	})
+}
+
+// flashMsg redraws the screen once the end-of-phase flash has expired.
+type flashMsg struct{}
+
+const flashDuration = 600 * time.Millisecond
+
+func doFlash() tea.Cmd {
+	return tea.Tick(flashDuration, func(time.Time) tea.Msg {
+		return flashMsg{}
 	})
 }
 
+// warmupKeys are the timer keys that work during the warmup.
+var warmupKeys = map[string]bool{" ": true, "s": true, "m": true, "f": true, "r": true}
+
+// signalMsg carries SIGUSR1 or SIGUSR2, as the key it stands for.
+type signalMsg struct {
+	key tea.KeyMsg
+}
+
+// remaining is the time left until end, rounded up to whole seconds for
+// display, so the shown second only changes on a whole-second boundary and
+// reaches zero exactly at end.
+func remaining(end, now time.Time) time.Duration {
+	d := end.Sub(now)
+	if d > 0 {
+		d = (d + time.Second - 1).Truncate(time.Second)
+	}
+	return d
+}
+
+// wallNow is the current time without its monotonic clock reading. The
+// monotonic clock stops while the machine is suspended, so deadlines taken
+// from it would run late by however long the lid was shut; measured on the
+// wall clock, the first tick after waking sees the real time left, and a
+// phase that ended during sleep finishes (and notifies) straight away.
+func wallNow() time.Time {
+	return time.Now().Round(0)
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	next.theme = next.themeAt(time.Now())
+	if next.opts.status != nil {
+		next.opts.status.set(next.snapshot())
+	}
+	if next.opts.statusFile != nil {
+		next.opts.statusFile.write(next.statusLine())
+	}
+	if next.opts.stateFile != nil {
+		next.opts.stateFile.update(next)
+	}
+	return next, cmd
+}
+
+func (m model) update(msg tea.Msg) (model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	case tea.WindowSizeMsg:
@@ -155,33 +686,192 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		return m, nil
 
+	case alarmMsg:
+		if msg.id != m.timerID || !m.awaitingAck {
+			return m, nil
+		}
+		m.playSoundFor(m.alarmFor)
+		return m, doAlarm(m.timerID)
+
+	case flashMsg:
+		return m, nil
+
 	// <--- CHANGED: Check ID matches. If not, this is an old "ghost" tick.
 	case tickMsg:
 		if msg.id != m.timerID {
 			return m, nil
 		}
 
+		if m.state == stateRunning && !m.paused && m.countUp {
+			m.timeElapsed = msg.at.Sub(m.countStart).Truncate(time.Second)
+			return m, doTick(m.timerID, m.opts.tickEvery)
+		}
+
+		if m.state == stateRunning && !m.paused && m.inMicroBreak {
+			m.microLeft = remaining(m.microEnd, msg.at)
+			if m.microLeft <= 0 {
+				return m.endMicroBreak()
+			}
+			m.maybePip()
+			return m, doTick(m.timerID, m.opts.tickEvery)
+		}
+
+		if m.state == stateRunning && !m.paused && m.timeLeft > 0 && m.timerType == typeWork &&
+			m.opts.idleAfter > 0 && msg.at.Sub(m.lastActivity) >= m.opts.idleAfter {
+			m.paused = true
+			m.idlePaused = true
+			m.pausedAt = msg.at
+			m.timerID++
+			return m, nil
+		}
+
 		if m.state == stateRunning && !m.paused && m.timeLeft > 0 {
-			m.timeLeft -= time.Second
+			// A tick after a suspend can land long past the deadline;
+			// only the time up to it was spent in this phase.
+			delta := min(msg.at.Sub(m.lastTick), max(m.endTime.Sub(m.lastTick), 0))
+			m.lastTick = msg.at
+			m.timeLeft = remaining(m.endTime, msg.at)
+			m.phaseElapsed += delta
+			if m.timerType == typeWork && m.opts.microEvery > 0 && m.opts.microBreak > 0 && m.timeLeft > 0 {
+				m.workSinceMicro += delta
+				if m.workSinceMicro >= m.opts.microEvery {
+					return m.startMicroBreak()
+				}
+			}
 			if m.timeLeft <= 0 {
 				return m.handleTimerFinish()
 			}
-			return m, doTick(m.timerID) // <--- CHANGED: Pass ID
+			m.maybePip()
+			return m, doTick(m.timerID, m.opts.tickEvery) // <--- CHANGED: Pass ID
+		}
+		return m, nil
+
+	case signalMsg:
+		// Only act where the key would: not in setup or while typing.
+		if m.state != stateRunning || m.editingTask || m.confirmingQuit {
+			return m, nil
+		}
+		return m.update(msg.key)
+
+	case tea.MouseMsg:
+		if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+			return m, nil
+		}
+		// A click on a button is the same as pressing its key.
+		if key, ok := m.clicks.hit(msg.X, msg.Y); ok {
+			return m.update(key)
 		}
 		return m, nil
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		if m.state == stateDone {
+			return m, tea.Quit
+		}
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+
+		m.lastActivity = time.Now()
+		// Coming back to the keyboard resumes an idle pause.
+		if m.idlePaused {
+			m.idlePaused = false
+			m.paused = false
+			m.timerID++
+			m.pausedAccumulated += time.Since(m.pausedAt)
+			m.resumeClock()
+			return m, doTick(m.timerID, m.opts.tickEvery)
+		}
+
+		if msg.String() == "z" && m.canSnooze() {
+			return m.snoozeBreak()
+		}
+		if msg.String() == "o" && m.canOvertime() {
+			return m.startOvertime()
+		}
+
+		// Any key silences a persistent alarm and moves on. The phase
+		// starts now, so the time the alarm rang isn't taken out of it.
+		// "q" silences it too, but holds the phase and goes on to ask
+		// about quitting.
+		if m.awaitingAck && msg.String() == "q" {
+			m.awaitingAck = false
+			m.waitingToStart = true
+			m.timerID++
+		} else if m.awaitingAck {
+			m.awaitingAck = false
+			m.timerID++
+			m.resumeClock()
+			return m.startOrHold()
+		}
+
+		// Setup uses F1 for help, since "?" could be meant for a field.
+		// With help open, any key closes it.
+		if m.state == stateSetup && (msg.String() == "f1" || m.showHelp) {
+			m.showHelp = !m.showHelp
+			return m, nil
+		}
+		if m.state == stateRunning && !m.editingTask && !m.confirmingQuit {
+			if msg.String() == "?" || msg.String() == "f1" {
+				m.showHelp = !m.showHelp
+				return m, nil
+			}
+			if m.showHelp && !timerKeys[msg.String()] {
+				m.showHelp = false
+				return m, nil
+			}
+		}
+
+		if m.editingTask {
+			switch msg.String() {
+			case "enter":
+				m.task = strings.TrimSpace(m.taskInput.Value())
+				m.editingTask = false
+				m.taskInput.Blur()
+				return m, nil
+			case "esc":
+				m.editingTask = false
+				m.taskInput.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.taskInput, cmd = m.taskInput.Update(msg)
+			return m, cmd
+		}
+
+		// A stray "q" mid-session asks first. "y" quits, "n"/esc cancels,
+		// and any other key cancels and then does its usual thing. The
+		// setup screen's Task field takes "q" as text.
+		if m.confirmingQuit {
+			m.confirmingQuit = false
+			switch msg.String() {
+			case "y", "Y":
+				return m, tea.Quit
+			case "n", "N", "esc", "q":
+				return m, nil
+			}
+		} else if msg.String() == "q" && !(m.state == stateSetup && m.focusIndex == 4) {
+			if m.state == stateRunning {
+				m.confirmingQuit = true
+				return m, nil
+			}
 			return m, tea.Quit
 		}
 
 		if m.state == stateSetup {
+			if m.stepInput(msg.String()) {
+				return m, nil
+			}
 			switch msg.String() {
+			case "ctrl+r":
+				m.repeat = !m.repeat
+				return m, nil
 			case "tab", "shift+tab", "enter", "up", "down":
 				s := msg.String()
 				if s == "enter" && m.focusIndex == len(m.inputs)-1 {
-					return m.startTimer()
+					if m.validateSetup() {
+						return m.startTimer()
+					}
+					return m, nil
 				}
 				if s == "up" || s == "shift+tab" {
 					m.focusIndex--
@@ -198,32 +888,121 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				for i := 0; i <= len(m.inputs)-1; i++ {
 					if i == m.focusIndex {
 						cmds[i] = m.inputs[i].Focus()
-						m.inputs[i].PromptStyle = lipgloss.NewStyle().Foreground(colorBlue)
+						m.inputs[i].PromptStyle = lipgloss.NewStyle().Foreground(m.theme.accent)
 					} else {
 						m.inputs[i].Blur()
-						m.inputs[i].PromptStyle = lipgloss.NewStyle().Foreground(colorSubtle)
+						m.inputs[i].PromptStyle = lipgloss.NewStyle().Foreground(m.theme.subtle)
 					}
 				}
 				return m, tea.Batch(cmds...)
 			}
 		}
 
+		// The warmup can only be paused, skipped or left; the rest waits
+		// for the work session.
+		if m.state == stateRunning && m.timerType == typeWarmup && !warmupKeys[msg.String()] {
+			return m, nil
+		}
+
 		if m.state == stateRunning {
 			switch msg.String() {
 			case " ":
+				held := m.waitingToStart
+				if !m.paused && m.limitsPauses() && m.pauses >= m.opts.maxPauses {
+					m.setNote("No more pauses this session.")
+					return m, nil
+				}
 				m.paused = !m.paused
-				if !m.paused {
+				m.waitingToStart = false
+				// Every pause/resume gets a fresh ID so a tick scheduled
+				// before the toggle can never run alongside the new loop,
+				// however fast SPACE is pressed.
+				m.timerID++
+				if m.paused {
+					m.pausedAt = time.Now()
+					if m.limitsPauses() {
+						m.pauses++
+					}
+				} else {
+					// Waiting for a held phase to start isn't a pause.
+					if !held {
+						m.pausedAccumulated += time.Since(m.pausedAt)
+					}
+					m.resumeClock()
 					// <--- CHANGED: Pass current ID when unpausing
-					return m, doTick(m.timerID)
+					return m, doTick(m.timerID, m.opts.tickEvery)
+				}
+			case "m":
+				m.mute = (m.mute + 1) % (muteAll + 1)
+			case "e":
+				m.showElapsed = !m.showElapsed
+			case "f":
+				m.focusMode = !m.focusMode
+			case "i":
+				if m.countUp || (m.timerType == typeWork && !m.inMicroBreak) {
+					m.interruptions++
+					m.setNote(fmt.Sprintf("interruption noted: %d", m.interruptions))
+				}
+			case "t":
+				m.editingTask = true
+				m.taskInput = textinput.New()
+				m.taskInput.Placeholder = "Task name"
+				m.taskInput.Width = 30
+				m.taskInput.SetValue(m.task)
+				m.taskInput.CursorEnd()
+				return m, m.taskInput.Focus()
+			case "+", "=", "shift+up":
+				if !m.countUp {
+					m.adjustPhaseDuration(time.Minute)
+				}
+			case "-", "shift+down":
+				if !m.countUp {
+					m.adjustPhaseDuration(-time.Minute)
+				}
+			case "p":
+				if !m.countUp && !m.inMicroBreak && m.timerType == typeWork {
+					return m.startInterjectedBreak()
+				}
+			case "b":
+				if !m.countUp {
+					return m.previousSession()
+				}
+			case "r":
+				if m.countUp {
+					m.timerID++
+					m.restartStopwatch()
+					if m.paused {
+						return m, nil
+					}
+					return m, doTick(m.timerID, m.opts.tickEvery)
+				}
+				return m.resetToSetup()
+			case "R":
+				if !m.countUp && !m.inMicroBreak {
+					return m.restartPhase()
+				}
+			case "tab":
+				if !m.countUp && !m.inMicroBreak && !m.interjected {
+					return m.togglePhase()
 				}
 			case "s":
+				if m.countUp {
+					return m.lapStopwatch()
+				}
+				if m.inMicroBreak {
+					return m.endMicroBreak()
+				}
 				return m.handleTimerFinish()
 			case "up":
-				m.timeLeft += time.Minute
+				m.extendPhase(time.Minute)
 			case "down":
-				if m.timeLeft > time.Minute {
-					m.timeLeft -= time.Minute
-				}
+				m.extendPhase(-time.Minute)
+			case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+				m.extendPhase(time.Duration(msg.String()[0]-'0') * time.Minute)
+			case "!", "@", "#", "$", "%", "^", "&", "*", "(":
+				// Shifted digits on a US layout subtract instead.
+				n := strings.Index("!@#$%^&*(", msg.String()) + 1
+				m.extendPhase(-time.Duration(n) * time.Minute)
 			}
 		}
 	}
@@ -246,148 +1025,1756 @@ func (m *model) updateInputs(msg tea.Msg) tea.Cmd {
 
 // --- Helpers ---
 
-func parseDurationInput(s string, defaultMin int) time.Duration {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return time.Duration(defaultMin) * time.Minute
-	}
-	if d, err := time.ParseDuration(s); err == nil {
+// parseDurationInput turns user input into a duration, trying in order:
+//
+//  1. Go duration syntax: "25s", "90m", "1h30m", "1.5h"
+//  2. hours with bare trailing minutes: "1h30"
+//  3. a bare number of minutes, decimals allowed: "25", "1.5"
+//  4. N blocks of any of the above: "2x25", "3x10m"
+//
+// Empty, negative or unparseable input falls back to def.
+func parseDurationInput(s string, def time.Duration) time.Duration {
+	if d, ok := parseDuration(strings.TrimSpace(s)); ok {
 		return d
 	}
-	if val, err := strconv.Atoi(s); err == nil {
-		return time.Duration(val) * time.Minute
-	}
-	return time.Duration(defaultMin) * time.Minute
+	return def
 }
 
-func playWindowsSound() {
-	if runtime.GOOS == "windows" {
-		go func() {
-			_ = exec.Command("powershell", "-c", "(New-Object Media.SoundPlayer 'C:\\Windows\\Media\\Windows Notify System Generic.wav').PlaySync()").Run()
-		}()
-	} else {
-		go beeep.Beep(beeep.DefaultFreq, beeep.DefaultDuration)
+// parseSessions reads the sessions input: a count from 1 to 99 such as
+// "4", or a time budget with a unit such as "2h" or "90m". ok is false for
+// anything else, including empty input.
+func parseSessions(s string) (count int, budget time.Duration, ok bool) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, 0, n >= 1 && n <= 99
+	}
+	if !strings.ContainsAny(strings.ToLower(s), "hms") {
+		return 0, 0, false
 	}
+	d, ok := parseDuration(s)
+	return 0, d, ok && d > 0
 }
 
-func (m model) startTimer() (model, tea.Cmd) {
-	m.workDuration = parseDurationInput(m.inputs[0].Value(), 30)
-	m.breakDuration = parseDurationInput(m.inputs[1].Value(), 5)
-	s, _ := strconv.Atoi(m.inputs[2].Value())
-	if s == 0 {
-		s = 4
+// sessionsFor is how many work sessions of length work it takes to fill
+// budget, capped at 99.
+func sessionsFor(budget, work time.Duration) int {
+	if budget <= 0 || work <= 0 {
+		return 0
 	}
-	m.sessionsTotal = s
-	m.currentSession = 1
-	m.state = stateRunning
-	m.timerType = typeWork
-	m.timeLeft = m.workDuration
-	m.paused = false
+	return int(min((budget+work-1)/work, 99))
+}
 
-	// <--- CHANGED: New session, New ID
-	m.timerID++
+func parseDuration(s string) (time.Duration, bool) {
+	s = strings.ToLower(s)
+	if s == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, d >= 0
+	}
+	if h, rest, ok := strings.Cut(s, "h"); ok && !strings.ContainsAny(rest, "hms") {
+		if d, err := time.ParseDuration(h + "h" + rest + "m"); err == nil {
+			return d, d >= 0
+		}
+	}
+	if val, err := strconv.ParseFloat(s, 64); err == nil {
+		if math.IsNaN(val) || val < 0 || val > math.MaxInt64/float64(time.Minute) {
+			return 0, false
+		}
+		return time.Duration(val * float64(time.Minute)), true
+	}
+	if n, block, ok := strings.Cut(s, "x"); ok {
+		count, err := strconv.Atoi(strings.TrimSpace(n))
+		d, dok := parseDuration(strings.TrimSpace(block))
+		if err == nil && dok && count > 0 && d <= math.MaxInt64/time.Duration(count) {
+			return time.Duration(count) * d, true
+		}
+	}
+	return 0, false
+}
 
-	return m, doTick(m.timerID)
+// parseCutoff parses a -until time of day such as "18:00" into the time
+// since midnight.
+func parseCutoff(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid -until %q: want HH:MM, e.g. 18:00", s)
+	}
+	d := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	// Midnight is the start of the day, so every start would be past it.
+	if d == 0 {
+		return 0, fmt.Errorf("invalid -until %q: pick a time after midnight, e.g. 23:59", s)
+	}
+	return d, nil
 }
 
-func (m model) handleTimerFinish() (model, tea.Cmd) {
-	playWindowsSound()
+// formatCutoff shows a -until time of day the way the clock is set to.
+func formatCutoff(until time.Duration, clock12 bool) string {
+	return formatClock(startOfDay(time.Now()).Add(until), clock12)
+}
 
-	// <--- CHANGED: Increment ID. This invalidates any old ticks still in the pipeline.
-	m.timerID++
+// pastCutoff reports whether now is at or after the -until time of day.
+func (m model) pastCutoff(now time.Time) bool {
+	return m.opts.until > 0 && now.Sub(startOfDay(now)) >= m.opts.until
+}
 
-	msg := ""
-	if m.timerType == typeWork {
-		msg = "Work session finished! Time for a break."
-		_ = beeep.Notify("Pomodoro", msg, "")
-		m.timerType = typeBreak
-		m.timeLeft = m.breakDuration
-	} else {
-		msg = "Break finished! Back to work."
-		_ = beeep.Notify("Pomodoro", msg, "")
-		m.timerType = typeWork
-		m.timeLeft = m.workDuration
-		m.currentSession++
+// parseRatio parses a work:break ratio such as "5:1" into work/break.
+func parseRatio(s string) (float64, error) {
+	a, b, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid ratio %q: want WORK:BREAK, e.g. 5:1", s)
 	}
-
-	if m.currentSession > m.sessionsTotal {
-		_ = beeep.Notify("Pomodoro", "All sessions completed!", "")
-		return m, tea.Quit
+	w, err1 := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	r, err2 := strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if err1 != nil || err2 != nil || math.IsNaN(w) || math.IsNaN(r) || math.IsInf(w, 0) || math.IsInf(r, 0) {
+		return 0, fmt.Errorf("invalid ratio %q: want WORK:BREAK, e.g. 5:1", s)
 	}
-
-	// <--- CHANGED: Unpause automatically and start new tick loop with new ID
-	m.paused = false
-	return m, doTick(m.timerID)
+	if w <= 0 || r <= 0 {
+		return 0, fmt.Errorf("invalid ratio %q: both sides must be positive", s)
+	}
+	return w / r, nil
 }
 
-// --- ASCII Renderer --- (No changes needed below)
-
-func renderBigTime(d time.Duration, color lipgloss.Color) string {
-	minutes := int(d.Minutes())
-	seconds := int(d.Seconds()) % 60
-	timeStr := fmt.Sprintf("%02d:%02d", minutes, seconds)
-	height := 5
-	lines := make([]string, height)
-	for _, char := range timeStr {
-		block, ok := bigDigits[char]
-		if !ok {
-			continue
-		}
-		for i := 0; i < height; i++ {
-			lines[i] += block[i] + " "
+// parseSchedule parses a list of WORK/BREAK pairs such as "50/10,25/5".
+// Durations use the same syntax as the positional arguments.
+func parseSchedule(s string) ([]phase, error) {
+	var steps []phase
+	for _, part := range strings.Split(s, ",") {
+		w, b, ok := strings.Cut(strings.TrimSpace(part), "/")
+		work, wok := parseDuration(strings.TrimSpace(w))
+		brk, bok := parseDuration(strings.TrimSpace(b))
+		if !ok || !wok || !bok || work <= 0 {
+			return nil, fmt.Errorf("invalid schedule step %q: want WORK/BREAK, e.g. 50/10", part)
 		}
+		steps = append(steps, phase{work: work, brk: brk})
 	}
-	fullBlock := strings.Join(lines, "\n")
-	return lipgloss.NewStyle().Foreground(color).Render(fullBlock)
+	return steps, nil
 }
 
-func (m model) View() string {
-	if m.width == 0 {
-		return "Loading..."
+// formatClock shows a time of day as "15:04", or as "3:04 PM" on a
+// 12-hour clock (midnight is 12:00 AM, noon 12:00 PM).
+func formatClock(t time.Time, clock12 bool) string {
+	if clock12 {
+		return t.Format("3:04 PM")
 	}
-	var s string
-	if m.state == stateSetup {
-		s = m.viewSetup()
-	} else {
-		s = m.viewTimer()
+	return t.Format("15:04")
+}
+
+// localeUses12h guesses the clock style from the locale settings: the
+// English locales that write times on a 12-hour clock, else 24-hour.
+func localeUses12h() bool {
+	for _, key := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			for _, loc := range []string{"en_US", "en_CA", "en_AU", "en_NZ", "en_PH", "en_IN"} {
+				if strings.HasPrefix(v, loc) {
+					return true
+				}
+			}
+			return false
+		}
 	}
-	return styleContainer.Width(m.width).Height(m.height).Render(s)
+	return false
+}
+
+// formatDurationInput renders d the way a user would type it back in:
+// whole minutes as a bare number, anything else in Go duration syntax.
+func formatDurationInput(d time.Duration) string {
+	if d%time.Minute == 0 {
+		return strconv.Itoa(int(d / time.Minute))
+	}
+	return d.String()
+}
+
+// formatDuration renders d compactly for display, e.g. "25m", "1h40m", "2m30s".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d == 0 {
+		return "0m"
+	}
+	h, mins, secs := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	var b strings.Builder
+	if h > 0 {
+		fmt.Fprintf(&b, "%dh", h)
+	}
+	if mins > 0 {
+		fmt.Fprintf(&b, "%dm", mins)
+	}
+	if secs > 0 {
+		fmt.Fprintf(&b, "%ds", secs)
+	}
+	return b.String()
+}
+
+// setPhaseTime starts a fresh countdown of d for the current phase.
+func (m *model) setPhaseTime(d time.Duration) {
+	now := wallNow()
+	m.timeLeft = d
+	m.currentPhaseDuration = d
+	m.endTime = now.Add(d)
+	m.lastTick = now
+	m.lastPip = 0
+	m.pausedAccumulated = 0
+	m.pauses = 0
+}
+
+// resumeClock pushes the deadlines forward by however long we were paused.
+func (m *model) resumeClock() {
+	now := wallNow()
+	paused := now.Sub(m.pausedAt)
+	m.endTime = m.endTime.Add(paused)
+	m.microEnd = m.microEnd.Add(paused)
+	m.countStart = m.countStart.Add(paused)
+	m.lastTick = now
+}
+
+// alertsFor reports whether the end of a phase of type ending should make
+// a sound and send a notification under the current mute mode.
+func (m model) alertsFor(ending timerType) (sound, notify bool) {
+	switch m.mute {
+	case muteWork:
+		return ending == typeBreak, ending == typeBreak
+	case muteAll:
+		return false, true
+	}
+	return true, true
+}
+
+// playSound plays the boundary alert unless the mute mode silences it.
+func (m model) playSound() {
+	m.playSoundFor(m.timerType)
+}
+
+// playSoundFor plays the alert for the end of a phase of type ending.
+func (m model) playSoundFor(ending timerType) {
+	if sound, _ := m.alertsFor(ending); sound {
+		m.opts.notifier.Sound()
+	}
+}
+
+// notify sends a desktop notification about the phase in progress.
+func (m model) notify(title, msg string) {
+	m.notifyFor(m.timerType, title, msg)
+}
+
+// notifyFor sends a notification for the end of a phase of type ending.
+func (m model) notifyFor(ending timerType, title, msg string) {
+	if _, ok := m.alertsFor(ending); !ok {
+		return
+	}
+	phase, session := m.phaseName(), m.currentSession
+	if m.completed {
+		phase, session = "done", min(session, m.sessionsTotal)
+	}
+	m.opts.notifier.Notify(Alert{Title: title, Message: msg, Phase: phase, Session: session})
+}
+
+// untilBoundary returns how long until the clock next changes phase,
+// counting micro-break boundaries inside a work session.
+func (m model) untilBoundary() time.Duration {
+	if m.inMicroBreak {
+		return m.microLeft
+	}
+	left := m.timeLeft
+	if m.timerType == typeWork && m.opts.microEvery > 0 && m.opts.microBreak > 0 {
+		if next := m.opts.microEvery - m.workSinceMicro; next < left {
+			left = next
+		}
+	}
+	return left
+}
+
+// maybePip sounds a pip on each of the final countdown seconds before a
+// boundary. The boundary itself is left to the regular alert so the two
+// never overlap.
+func (m *model) maybePip() {
+	if sound, _ := m.alertsFor(m.timerType); m.opts.countdown <= 0 || !sound {
+		return
+	}
+	left := m.untilBoundary().Round(time.Second)
+	// With sub-second ticks the same second is seen more than once.
+	if left > 0 && left <= time.Duration(m.opts.countdown)*time.Second && left != m.lastPip {
+		m.lastPip = left
+		m.opts.notifier.Pip()
+	}
+}
+
+// prefillSetup fills the setup fields for -edit: each from its argument,
+// or else from the default the flags and config settled on. Anything that
+// can't be read is flagged straight away.
+func (m *model) prefillSetup(workArg, breakArg, sessArg, longArg string) {
+	values := []string{workArg, breakArg, sessArg, longArg}
+	defaults := []string{
+		formatDurationInput(m.opts.work),
+		formatDurationInput(m.opts.brk),
+		strconv.Itoa(m.opts.sessions),
+		formatDurationInput(m.opts.longBreak),
+	}
+	for i, v := range values {
+		if v == "" {
+			v = defaults[i]
+		}
+		m.inputs[i].SetValue(v)
+		m.inputs[i].CursorEnd()
+	}
+	m.validateSetup()
+}
+
+// stepInput nudges the focused setup field with the arrow keys: a minute
+// on a duration, one on the session count, or five with SHIFT. It reports
+// false, leaving the key to move focus, when the field holds nothing it
+// can step (including a blank field or a time budget).
+func (m *model) stepInput(key string) bool {
+	var step int
+	switch key {
+	case "up":
+		step = 1
+	case "down":
+		step = -1
+	case "shift+up":
+		step = 5
+	case "shift+down":
+		step = -5
+	default:
+		return false
+	}
+	in := &m.inputs[m.focusIndex]
+	v := strings.TrimSpace(in.Value())
+	switch m.focusIndex {
+	case 0, 1, 3:
+		d, ok := parseDuration(v)
+		if !ok {
+			return false
+		}
+		// Work needs something to count down; a break of 0 skips it.
+		floor := time.Duration(0)
+		if m.focusIndex == 0 {
+			floor = time.Minute
+		}
+		in.SetValue(formatDurationInput(max(d+time.Duration(step)*time.Minute, floor)))
+	case 2:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return false
+		}
+		in.SetValue(strconv.Itoa(min(max(n+step, 1), 99)))
+	default:
+		return false
+	}
+	in.CursorEnd()
+	return true
+}
+
+// validateSetup checks the setup inputs, recording the first problem in
+// setupError. Empty inputs are fine and fall back to the defaults.
+func (m *model) validateSetup() bool {
+	m.setupError = ""
+	labels := map[int]string{0: "Work duration", 1: "Break duration", 3: "Long break duration"}
+	for _, i := range []int{0, 1, 3} {
+		v := strings.TrimSpace(m.inputs[i].Value())
+		if v == "" {
+			continue
+		}
+		if d, ok := parseDuration(v); !ok || (d <= 0 && i == 0) {
+			m.setupError = fmt.Sprintf("%s: %q isn't a duration (try 25, 90s or 1h30m)", labels[i], v)
+			m.setupErrorField = i
+			return false
+		}
+	}
+	if v := strings.TrimSpace(m.inputs[2].Value()); v != "" {
+		if _, _, ok := parseSessions(v); !ok {
+			m.setupError = "Sessions must be a whole number from 1 to 99, or a time budget such as 2h"
+			m.setupErrorField = 2
+			return false
+		}
+	}
+	return true
+}
+
+func (m model) startTimer() (model, tea.Cmd) {
+	m.workDuration = parseDurationInput(m.inputs[0].Value(), m.opts.work)
+	m.breakDuration = parseDurationInput(m.inputs[1].Value(), m.opts.brk)
+	m.longBreakDuration = parseDurationInput(m.inputs[3].Value(), m.opts.longBreak)
+	m.task = strings.TrimSpace(m.inputs[4].Value())
+	s, budget, _ := parseSessions(m.inputs[2].Value())
+	if budget > 0 {
+		s = sessionsFor(budget, m.workDuration)
+	}
+	if s == 0 {
+		s = m.opts.sessions
+	}
+	m.sessionsTotal = s
+	m.budget, m.budgetBase = budget, m.focusedTotal
+	m.currentSession = 1
+	m.cycle = 0
+	m.state = stateRunning
+	m.timerType = typeWork
+	m.longBreak = false
+	m.interjected = false
+	m.interruptions = 0
+	m.setPhaseTime(m.workFor(1))
+	m.startWarmup()
+	// A start-paused session is held, so the wait isn't paused time.
+	m.paused = m.opts.startPaused
+	m.waitingToStart = m.opts.startPaused
+	m.pausedAt = time.Now()
+	m.inMicroBreak = false
+	m.workSinceMicro = 0
+	m.phaseElapsed = 0
+	m.snoozable = false
+	m.snoozing = false
+	m.overtime = false
+	m.overtimes = 0
+
+	// <--- CHANGED: New session, New ID
+	m.timerID++
+
+	return m, doTick(m.timerID, m.opts.tickEvery)
+}
+
+// startWarmup swaps the first work session for the -warmup countdown,
+// when there is one.
+func (m *model) startWarmup() {
+	if m.opts.warmup <= 0 {
+		return
+	}
+	m.timerType = typeWarmup
+	m.setPhaseTime(m.opts.warmup)
+}
+
+// endWarmup starts the first work session once the warmup runs out or is
+// skipped.
+func (m model) endWarmup() (model, tea.Cmd) {
+	m.playSound()
+	m.timerID++
+	m.timerType = typeWork
+	m.phaseElapsed = 0
+	m.setPhaseTime(m.workFor(m.currentSession))
+	m.runPhaseHook()
+	if m.paused {
+		m.pausedAt = time.Now()
+		return m, nil
+	}
+	return m, doTick(m.timerID, m.opts.tickEvery)
+}
+
+// startMicroBreak suspends the work countdown for a short micro-break.
+// The remaining work time is left untouched so the break doesn't eat into it.
+func (m model) startMicroBreak() (model, tea.Cmd) {
+	m.playSound()
+	m.timerID++
+	m.inMicroBreak = true
+	m.microLeft = m.opts.microBreak
+	m.microEnd = wallNow().Add(m.opts.microBreak)
+	m.workSinceMicro = 0
+	m.notify("Micro-break 👀", "Look away for a moment.")
+	return m, doTick(m.timerID, m.opts.tickEvery)
+}
+
+// endMicroBreak resumes the interrupted work countdown.
+func (m model) endMicroBreak() (model, tea.Cmd) {
+	m.playSound()
+	m.timerID++
+	m.inMicroBreak = false
+	m.microLeft = 0
+	// The work clock was frozen for the micro-break; re-anchor it.
+	now := wallNow()
+	m.endTime = now.Add(m.timeLeft)
+	m.lastTick = now
+	if m.paused {
+		m.pausedAt = now
+		return m, nil
+	}
+	return m, doTick(m.timerID, m.opts.tickEvery)
+}
+
+// restartStopwatch zeroes the count-up clock.
+func (m *model) restartStopwatch() {
+	now := wallNow()
+	m.countStart = now
+	m.pausedAt = now // so resuming a paused restart doesn't shift countStart
+	m.timeElapsed = 0
+	m.pausedAccumulated = 0
+	m.pauses = 0
+}
+
+// lapStopwatch records the elapsed stopwatch time as a session and
+// starts counting again from zero.
+func (m model) lapStopwatch() (model, tea.Cmd) {
+	if !m.opts.noLog {
+		logSession(m.opts.logFormat, sessionRecord{
+			Time:          time.Now(),
+			Duration:      int(m.timeElapsed.Seconds()),
+			Paused:        int(m.pausedAccumulated.Seconds()),
+			Session:       m.currentSession,
+			Task:          m.task,
+			Interruptions: m.interruptions,
+		})
+	}
+	m.interruptions = 0
+	m.countTowardGoal()
+	m.playSound()
+	m.timerID++
+	m.currentSession++
+	m.restartStopwatch()
+	if m.paused {
+		return m, nil
+	}
+	return m, doTick(m.timerID, m.opts.tickEvery)
+}
+
+// limitsPauses reports whether -max-pauses applies to the phase in
+// progress. Breaks, micro-breaks included, can be paused freely.
+func (m model) limitsPauses() bool {
+	return m.opts.maxPauses > 0 && m.timerType == typeWork && !m.inMicroBreak
+}
+
+// setNote shows a brief message in the status line.
+func (m *model) setNote(text string) {
+	m.note = text
+	m.noteUntil = time.Now().Add(3 * time.Second)
+}
+
+// adjustPhaseDuration changes the configured length of the current phase
+// type, so every later phase of that type uses the new value.
+func (m *model) adjustPhaseDuration(delta time.Duration) {
+	d, label := &m.workDuration, "Work"
+	if m.timerType == typeBreak {
+		d, label = &m.breakDuration, "Break"
+	}
+	// With a schedule, only this session's step changes.
+	if len(m.schedule) > 0 {
+		step := &m.schedule[m.scheduleIndex(m.currentSession)]
+		d = &step.work
+		if m.timerType == typeBreak {
+			d = &step.brk
+		}
+	}
+	if m.timerType == typeBreak && m.longBreak {
+		d, label = &m.longBreakDuration, "Long break"
+	}
+	// Shrinking stops at a minute, or where it is for shorter phases.
+	if delta < 0 {
+		*d = max(*d+delta, min(*d, time.Minute))
+	} else {
+		*d += delta
+	}
+	m.setNote(fmt.Sprintf("%s set to %s", label, formatDuration(*d)))
+}
+
+// maxPhaseLeft caps how far the number keys can stretch a phase.
+const maxPhaseLeft = 12 * time.Hour
+
+// extendPhase adds delta to the running phase, or takes it away when
+// negative, without going under a minute or over maxPhaseLeft.
+func (m *model) extendPhase(delta time.Duration) {
+	if m.inMicroBreak || m.countUp {
+		return
+	}
+	left := m.timeLeft + delta
+	if delta < 0 {
+		left = max(left, min(m.timeLeft, time.Minute))
+	} else {
+		left = min(left, max(m.timeLeft, maxPhaseLeft))
+	}
+	applied := left - m.timeLeft
+	m.timeLeft = left
+	m.endTime = m.endTime.Add(applied)
+	// The phase itself gets longer or shorter, so the progress bar and
+	// elapsed time don't jump.
+	m.currentPhaseDuration += applied
+	if applied >= 0 {
+		m.setNote("+" + formatDuration(applied))
+	} else {
+		m.setNote("-" + formatDuration(-applied))
+	}
+}
+
+// togglePhase flips between work and break on the spot, with the full
+// length of the new phase. Unlike skip, the session number stays put and
+// nothing is logged.
+func (m model) togglePhase() (model, tea.Cmd) {
+	if m.timerType == typeWork {
+		brk := m.breakAfter(m.currentSession)
+		if brk <= 0 {
+			m.setNote("No break configured")
+			return m, nil
+		}
+		// The work cut short is logged, so that focusedTotal matches the
+		// history.
+		if !m.opts.noLog {
+			logSession(m.opts.logFormat, sessionRecord{
+				Time:          time.Now(),
+				Duration:      int(m.phaseElapsed.Seconds()),
+				Paused:        int(m.pausedAccumulated.Seconds()),
+				Session:       m.currentSession,
+				Skipped:       true,
+				Task:          m.task,
+				Interruptions: m.interruptions,
+			})
+		}
+		m.interruptions = 0
+		m.focusedTotal += m.phaseElapsed
+		m.timerType = typeBreak
+		m.longBreak = m.isLongBreakAfter(m.currentSession)
+		m.pickBreakTip()
+		m.setPhaseTime(brk)
+	} else {
+		m.breakTotal += m.phaseElapsed
+		m.timerType = typeWork
+		m.longBreak = false
+		m.setPhaseTime(m.workFor(m.currentSession))
+	}
+	m.timerID++
+	m.phaseElapsed = 0
+	m.workSinceMicro = 0
+	m.snoozable = false
+	m.snoozing = false
+	m.overtime = false
+	m.waitingToStart = false
+	m.runPhaseHook()
+	return m.startOrHold()
+}
+
+// restartPhase puts the current phase's clock back to its full length
+// without moving to another session or phase.
+func (m model) restartPhase() (model, tea.Cmd) {
+	m.timerID++
+	m.setPhaseTime(m.currentPhaseDuration)
+	m.phaseElapsed = 0
+	if m.timerType == typeWork {
+		m.workSinceMicro = 0
+	}
+	m.setNote("Restarted")
+	if m.paused {
+		m.pausedAt = time.Now()
+		return m, nil
+	}
+	return m, doTick(m.timerID, m.opts.tickEvery)
+}
+
+// previousSession goes back one step: from a break to the work session it
+// followed, or from a work session to the one before it.
+func (m model) previousSession() (model, tea.Cmd) {
+	m.timerID++
+	if m.timerType == typeWork && m.currentSession > 1 {
+		m.currentSession--
+	}
+	m.timerType = typeWork
+	m.longBreak = false
+	m.interjected = false
+	m.snoozable = false
+	m.snoozing = false
+	m.overtime = false
+	m.overtimes = 0
+	m.inMicroBreak = false
+	m.workSinceMicro = 0
+	m.phaseElapsed = 0
+	m.interruptions = 0
+	m.setPhaseTime(m.workFor(m.currentSession))
+	if m.paused {
+		m.pausedAt = time.Now()
+		return m, nil
+	}
+	return m, doTick(m.timerID, m.opts.tickEvery)
+}
+
+// resetToSetup abandons the running timer and returns to the setup screen
+// with the current settings filled in, ready to be tweaked.
+func (m model) resetToSetup() (model, tea.Cmd) {
+	m.timerID++
+	m.state = stateSetup
+	m.paused = false
+	m.waitingToStart = false
+	m.interjected = false
+	m.inMicroBreak = false
+	m.wasReset = true
+
+	m.inputs[0].SetValue(formatDurationInput(m.workDuration))
+	m.inputs[1].SetValue(formatDurationInput(m.breakDuration))
+	if m.budget > 0 {
+		m.inputs[2].SetValue(formatDuration(m.budget))
+	} else {
+		m.inputs[2].SetValue(strconv.Itoa(m.sessionsTotal))
+	}
+	m.inputs[3].SetValue(formatDurationInput(m.longBreakDuration))
+	m.inputs[4].SetValue(m.task)
+
+	m.focusIndex = 0
+	for i := range m.inputs {
+		m.inputs[i].Blur()
+		m.inputs[i].PromptStyle = lipgloss.NewStyle().Foreground(m.theme.subtle)
+	}
+	m.inputs[0].PromptStyle = lipgloss.NewStyle().Foreground(m.theme.accent)
+	return m, m.inputs[0].Focus()
+}
+
+// handleTimerFinish ends the current phase with a short flash in the
+// colour of the phase that just finished.
+func (m model) handleTimerFinish() (model, tea.Cmd) {
+	if m.timerType == typeWarmup {
+		return m.endWarmup()
+	}
+	m.flashColor = m.theme.work
+	if m.timerType == typeBreak {
+		m.flashColor = m.theme.brk
+		if m.longBreak {
+			m.flashColor = m.theme.longBreak
+		}
+	}
+	m.flashUntil = time.Now().Add(flashDuration)
+	m, cmd := m.finishPhase()
+	return m, tea.Batch(cmd, doFlash())
+}
+
+func (m model) finishPhase() (model, tea.Cmd) {
+	m.playSound()
+	ending := m.timerType
+
+	// <--- CHANGED: Increment ID. This invalidates any old ticks still in the pipeline.
+	m.timerID++
+	if m.interjected {
+		return m.endInterjectedBreak()
+	}
+	m.inMicroBreak = false
+	m.microLeft = 0
+	m.workSinceMicro = 0
+
+	if m.timerType == typeWork && !m.opts.noLog {
+		logSession(m.opts.logFormat, sessionRecord{
+			Time:          time.Now(),
+			Duration:      int(m.phaseElapsed.Seconds()),
+			Paused:        int(m.pausedAccumulated.Seconds()),
+			Session:       m.currentSession,
+			Skipped:       m.timeLeft > 0,
+			Task:          m.task,
+			Interruptions: m.interruptions,
+			Overtime:      m.overtime,
+		})
+	}
+	if m.timerType == typeWork {
+		m.interruptions = 0
+		// With -skip-no-count a skipped session still moves the schedule
+		// on, but leaves the totals, the budget and -max-focus alone.
+		abandoned := m.opts.skipNoCount && m.timeLeft > 0 && !m.overtime
+		if abandoned {
+			m.sessionsSkipped++
+		} else {
+			m.focusedTotal += m.phaseElapsed
+		}
+		// Overtime is more of a session already counted.
+		if !m.overtime && !abandoned {
+			m.sessionsDone++
+			if m.timeLeft <= 0 {
+				m.countTowardGoal()
+			}
+		}
+		if m.pastCutoff(time.Now()) {
+			m.phaseElapsed = 0
+			m.completed = true
+			m.notifyFor(ending, "Day's work done 🌙", "It's past "+formatCutoff(m.opts.until, m.opts.clock12)+". No more sessions today.")
+			return m.finishRun()
+		}
+		// The limit is only checked here, between sessions, so it never
+		// cuts a session short.
+		if m.opts.maxFocus > 0 && m.focusedTotal >= m.opts.maxFocus {
+			m.phaseElapsed = 0
+			m.completed = true
+			m.notifyFor(ending, "Take a real break 🛑", fmt.Sprintf("That's %s of focus this run. Step away from the screen before you start again.", formatDuration(m.focusedTotal)))
+			return m.finishRun()
+		}
+		if m.budget > 0 {
+			// Plan just enough sessions for what's left of the budget, so
+			// skipped or stretched sessions move the finish line.
+			left := m.budget - (m.focusedTotal - m.budgetBase)
+			m.sessionsTotal = m.currentSession + sessionsFor(left, m.workDuration)
+		}
+	} else {
+		m.breakTotal += m.phaseElapsed
+	}
+	// The planned length of the phase, including any live adjustments.
+	planned := m.phaseElapsed + max(m.timeLeft, 0)
+	m.phaseElapsed = 0
+	if m.timerType == typeWork {
+		if m.overtime {
+			planned += m.overtimeWork
+		}
+		m.overtimeWork = planned
+		m.overtime = false
+	}
+
+	msg := ""
+	brk := m.shortBreak(m.currentSession, planned)
+	long := m.isLongBreakAfter(m.currentSession)
+	if long {
+		brk = m.longBreakDuration
+	}
+	if m.timerType == typeWork && brk <= 0 {
+		// No break configured: go straight on to the next session.
+		m.currentSession++
+		m.snoozable = false
+		if m.currentSession <= m.sessionsTotal || m.loops() {
+			m.notifyFor(ending, "Next Session 🍅", "Work session finished! On to the next one.")
+		}
+	} else if m.timerType == typeWork {
+		m.timerType = typeBreak
+		m.longBreak = long
+		if m.longBreak {
+			msg = "Work session finished! Time for a long break."
+		} else {
+			msg = "Work session finished! Time for a break."
+		}
+		if m.pickBreakTip() {
+			msg += " " + m.breakTip
+		}
+		m.setPhaseTime(brk)
+		m.notifyFor(ending, "Break Time 🍅", msg)
+		m.snoozable = false
+	} else {
+		msg = "Break finished! Back to work."
+		m.notifyFor(ending, "Back to Work 💪", msg)
+		m.timerType = typeWork
+		m.longBreak = false
+		m.currentSession++
+		m.snoozable = true
+		m.snoozes = 0
+		m.overtimes = 0
+	}
+
+	if m.currentSession > m.sessionsTotal && m.loops() {
+		if !m.repeat {
+			m.setNote(fmt.Sprintf("All %d sessions done, starting over", m.sessionsTotal))
+			m.budgetBase = m.focusedTotal
+		}
+		m.currentSession = 1
+		m.cycle++
+	}
+	if m.timerType == typeWork {
+		m.setPhaseTime(m.workFor(m.currentSession))
+	}
+
+	if m.currentSession > m.sessionsTotal {
+		m.completed = true
+		m.notifyFor(ending, "Pomodoro 🎉", "All sessions completed!")
+		return m.finishRun()
+	}
+
+	return m.beginPhase(ending)
+}
+
+// loops reports whether the run starts over after its last session, with
+// -repeat or -on-complete loop.
+func (m model) loops() bool {
+	return m.repeat || m.opts.onComplete == "loop"
+}
+
+// finishRun ends a completed run the way -on-complete asks: quit, or stay
+// on the summary until a key is pressed.
+func (m model) finishRun() (model, tea.Cmd) {
+	if m.opts.onComplete == "stay" {
+		m.state = stateDone
+		m.paused = false
+		return m, nil
+	}
+	return m, tea.Quit
+}
+
+// viewDone is the summary screen shown by -on-complete stay.
+func (m model) viewDone() string {
+	title := lipgloss.NewStyle().Bold(true).Foreground(m.theme.accent).Render("ALL DONE 🎉")
+	summary := lipgloss.NewStyle().Foreground(m.theme.work).Render(m.summary())
+	help := styleHelp.Foreground(m.theme.subtle).Render("Press any key to quit")
+	return lipgloss.JoinVertical(lipgloss.Center, title, "", summary, help)
+}
+
+// beginPhase starts the tick loop for a phase that was just set up, or holds
+// it for the user when auto-start is off for that phase type. ending is the
+// type of the phase before it.
+func (m model) beginPhase(ending timerType) (model, tea.Cmd) {
+	m.runPhaseHook()
+	if m.opts.persistAlarm {
+		m.awaitingAck = true
+		m.alarmFor = ending
+		m.paused = true
+		m.pausedAt = time.Now()
+		return m, doAlarm(m.timerID)
+	}
+	return m.startOrHold()
+}
+
+// startOrHold runs the current phase, or holds it until SPACE when
+// auto-start is off for its type.
+func (m model) startOrHold() (model, tea.Cmd) {
+	// <--- CHANGED: Unpause automatically and start new tick loop with new ID
+	autoStart := m.opts.autoStartWork
+	if m.timerType == typeBreak {
+		autoStart = m.opts.autoStartBreak
+	}
+	if !autoStart {
+		m.paused = true
+		m.waitingToStart = true
+		m.pausedAt = time.Now()
+		return m, nil
+	}
+	m.paused = false
+	return m, doTick(m.timerID, m.opts.tickEvery)
+}
+
+// startInterjectedBreak takes an unscheduled break in the middle of a work
+// session. The remaining work is stashed and picked up again afterwards.
+func (m model) startInterjectedBreak() (model, tea.Cmd) {
+	m.playSound()
+	msg := "Unscheduled break. Your work session is saved."
+	if m.pickBreakTip() {
+		msg += " " + m.breakTip
+	}
+	m.notify("Break Time 🍅", msg)
+	m.timerID++
+	m.interjected = true
+	m.stashedTimeLeft = m.timeLeft
+	m.stashedPhaseDuration = m.currentPhaseDuration
+	m.stashedElapsed = m.phaseElapsed
+	m.stashedPaused = m.pausedAccumulated
+	m.stashedPauses = m.pauses
+	m.timerType = typeBreak
+	m.longBreak = false
+	m.phaseElapsed = 0
+	m.setPhaseTime(m.shortBreak(m.currentSession, m.workFor(m.currentSession)))
+	return m.beginPhase(typeWork)
+}
+
+// endInterjectedBreak restores the work session that was interrupted by an
+// unscheduled break, without counting a new session.
+func (m model) endInterjectedBreak() (model, tea.Cmd) {
+	m.notify("Back to Work 💪", "Break finished! Picking up where you left off.")
+	m.breakTotal += m.phaseElapsed
+	m.interjected = false
+	m.snoozing = false
+	m.timerType = typeWork
+	m.setPhaseTime(m.stashedTimeLeft)
+	m.currentPhaseDuration = m.stashedPhaseDuration
+	m.phaseElapsed = m.stashedElapsed
+	m.pausedAccumulated = m.stashedPaused
+	m.pauses = m.stashedPauses
+	return m.beginPhase(typeBreak)
+}
+
+// goalProgress is how many pomodoros count toward today's goal, starting
+// again from zero once the local date has moved on.
+func (m model) goalProgress() int {
+	if !startOfDay(time.Now()).Equal(m.goalDay) {
+		return 0
+	}
+	return m.goalDone
+}
+
+// countTowardGoal records a finished pomodoro and celebrates, once per day,
+// when the daily goal is reached.
+func (m *model) countTowardGoal() {
+	if m.opts.goal <= 0 {
+		return
+	}
+	if today := startOfDay(time.Now()); !today.Equal(m.goalDay) {
+		m.goalDay, m.goalDone, m.goalNotified = today, 0, false
+	}
+	m.goalDone++
+	if m.goalDone >= m.opts.goal && !m.goalNotified {
+		m.goalNotified = true
+		m.notify("Daily goal reached 🎉", fmt.Sprintf("%d pomodoros today. Nice work!", m.goalDone))
+	}
+}
+
+// renderGoal draws the daily goal line, e.g. "Daily goal: 3/8 🍅🍅🍅░░░░░".
+// The icons are left out for goals too long to fit on one line.
+func renderGoal(done, goal int) string {
+	s := fmt.Sprintf("Daily goal: %d/%d", done, goal)
+	if goal <= 12 {
+		filled := min(done, goal)
+		s += " " + strings.Repeat("🍅", filled) + strings.Repeat("░", goal-filled)
+	}
+	return s
+}
+
+// renderBudget shows progress toward a time budget, e.g.
+// "Budget: 50m of 2h focused (41%)".
+func renderBudget(done, budget time.Duration) string {
+	pct := min(int(100*done/budget), 100)
+	return fmt.Sprintf("Budget: %s of %s focused (%d%%)", formatDuration(done), formatDuration(budget), pct)
+}
+
+// budgetFocused is the work done toward the time budget, counting the
+// session in progress.
+func (m model) budgetFocused() time.Duration {
+	d := m.focusedTotal - m.budgetBase
+	switch {
+	case m.interjected:
+		d += m.stashedElapsed
+	case m.timerType == typeWork:
+		d += m.phaseElapsed
+	}
+	return d
+}
+
+// snoozeWindow is how far into an automatically started work session a
+// snooze is still offered.
+const snoozeWindow = time.Minute
+
+// canSnooze reports whether the work session that just followed a break
+// can still be put off with a snooze.
+func (m model) canSnooze() bool {
+	if m.state != stateRunning || m.timerType != typeWork || m.countUp || m.inMicroBreak {
+		return false
+	}
+	if !m.snoozable || m.opts.snooze <= 0 || m.snoozes >= m.opts.maxSnoozes {
+		return false
+	}
+	return m.waitingToStart || m.awaitingAck || m.phaseElapsed < snoozeWindow
+}
+
+// snoozeBreak puts the upcoming work session off for opts.snooze. The
+// session is restarted in full once the snooze is over.
+func (m model) snoozeBreak() (model, tea.Cmd) {
+	m.snoozes++
+	m.snoozing = true
+	m.awaitingAck = false
+	m.waitingToStart = false
+	m.paused = false
+	m.timerID++
+	m.setPhaseTime(m.workFor(m.currentSession))
+	m.interjected = true
+	m.stashedTimeLeft = m.timeLeft
+	m.stashedPhaseDuration = m.currentPhaseDuration
+	m.stashedElapsed = 0
+	m.stashedPaused = 0
+	m.stashedPauses = 0
+	m.workSinceMicro = 0
+	m.timerType = typeBreak
+	m.longBreak = false
+	m.phaseElapsed = 0
+	m.setPhaseTime(m.opts.snooze)
+	m.notify("Snoozed 😴", fmt.Sprintf("%s more break (snooze %d of %d).", formatDuration(m.opts.snooze), m.snoozes, m.opts.maxSnoozes))
+	return m, doTick(m.timerID, m.opts.tickEvery)
+}
+
+// canOvertime reports whether the break that just began can still be put
+// off with some overtime.
+func (m model) canOvertime() bool {
+	if m.state != stateRunning || m.timerType != typeBreak || m.countUp || m.interjected || m.inMicroBreak {
+		return false
+	}
+	if m.opts.overtime <= 0 || m.overtimes >= m.opts.maxOvertimes {
+		return false
+	}
+	return m.waitingToStart || m.awaitingAck || m.phaseElapsed < snoozeWindow
+}
+
+// startOvertime goes back to work for opts.overtime on the session that
+// just ended. The break starts over once the overtime is done.
+func (m model) startOvertime() (model, tea.Cmd) {
+	m.overtimes++
+	m.overtime = true
+	m.awaitingAck = false
+	m.waitingToStart = false
+	m.paused = false
+	m.timerID++
+	m.breakTotal += m.phaseElapsed
+	m.timerType = typeWork
+	m.longBreak = false
+	m.breakTip = ""
+	m.phaseElapsed = 0
+	m.workSinceMicro = 0
+	m.setPhaseTime(m.opts.overtime)
+	m.notify("Overtime ⏱", fmt.Sprintf("%s more work (overtime %d of %d).", formatDuration(m.opts.overtime), m.overtimes, m.opts.maxOvertimes))
+	return m, doTick(m.timerID, m.opts.tickEvery)
+}
+
+// pickBreakTip chooses the suggestion for the break that is starting. It
+// reports false when tips are turned off.
+func (m *model) pickBreakTip() bool {
+	if m.opts.noTips || len(breakTips) == 0 {
+		m.breakTip = ""
+		return false
+	}
+	m.breakTip = breakTips[rand.IntN(len(breakTips))]
+	return true
+}
+
+// isLongBreakAfter reports whether work session n of the current cycle is
+// followed by a long break. Sessions are counted across repeat cycles so the
+// interval stays regular when the session number wraps.
+func (m model) isLongBreakAfter(n int) bool {
+	return (m.cycle*m.sessionsTotal+n)%m.longBreakEvery == 0
+}
+
+// summary describes the finished run, e.g.
+// "You focused for 1h40m across 4 sessions (took 20m of breaks)."
+// Sessions left out by -skip-no-count get a sentence of their own.
+func (m model) summary() string {
+	noun := "sessions"
+	if m.sessionsDone == 1 {
+		noun = "session"
+	}
+	s := fmt.Sprintf("You focused for %s across %d %s (took %s of breaks).",
+		formatDuration(m.focusedTotal), m.sessionsDone, noun, formatDuration(m.breakTotal))
+	switch m.sessionsSkipped {
+	case 0:
+	case 1:
+		s += " 1 skipped session wasn't counted."
+	default:
+		s += fmt.Sprintf(" %d skipped sessions weren't counted.", m.sessionsSkipped)
+	}
+	return s
+}
+
+// breakAfter is the length of the break that follows work session n.
+func (m model) breakAfter(n int) time.Duration {
+	if m.isLongBreakAfter(n) {
+		return m.longBreakDuration
+	}
+	return m.shortBreak(n, m.workFor(n))
+}
+
+// shortBreak is the length of the regular break after work session n,
+// which ran for w.
+func (m model) shortBreak(n int, w time.Duration) time.Duration {
+	if m.breakRatio > 0 {
+		return time.Duration(float64(w) / m.breakRatio).Round(time.Second)
+	}
+	if len(m.schedule) > 0 {
+		return m.schedule[m.scheduleIndex(n)].brk
+	}
+	return m.breakDuration
+}
+
+// scheduleIndex picks the schedule step for work session n. Sessions past
+// the end of the schedule repeat its last step.
+func (m model) scheduleIndex(n int) int {
+	return min(max(n, 1), len(m.schedule)) - 1
+}
+
+// workFor is the length of work session n.
+func (m model) workFor(n int) time.Duration {
+	if len(m.schedule) > 0 {
+		return m.schedule[m.scheduleIndex(n)].work
+	}
+	return m.workDuration
+}
+
+// microBreaksIn counts the micro-breaks that interrupt work of length w when
+// done already counts toward the next one.
+func (m model) microBreaksIn(w, done time.Duration) time.Duration {
+	if m.opts.microEvery <= 0 || m.opts.microBreak <= 0 || w <= 0 {
+		return 0
+	}
+	return m.opts.microBreak * ((done + w - 1) / m.opts.microEvery)
+}
+
+// remainingTotal estimates how long until every session, including the
+// break after the last one, has finished.
+func (m model) remainingTotal() time.Duration {
+	total := m.timeLeft
+	if m.inMicroBreak {
+		total += m.microLeft
+	}
+	if m.timerType == typeWork {
+		total += m.microBreaksIn(m.timeLeft, m.workSinceMicro)
+		total += m.breakAfter(m.currentSession)
+	}
+	if m.timerType == typeWarmup {
+		w := m.workFor(m.currentSession)
+		total += w + m.microBreaksIn(w, 0) + m.breakAfter(m.currentSession)
+	}
+	for n := m.currentSession + 1; n <= m.sessionsTotal; n++ {
+		w := m.workFor(n)
+		total += w + m.microBreaksIn(w, 0) + m.breakAfter(n)
+	}
+	return total
+}
+
+// --- ASCII Renderer --- (No changes needed below)
+
+// clockString formats d as MM:SS, switching to H:MM:SS from one hour up
+// (59:59 is followed by 1:00:00). Negative durations show as 00:00.
+func clockString(d time.Duration) string {
+	total := max(int(d.Seconds()), 0)
+	h, mins, secs := total/3600, total/60%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, mins, secs)
+	}
+	return fmt.Sprintf("%02d:%02d", mins, secs)
+}
+
+// bigTimeWidth is how many columns renderBigTime needs for d, including a
+// little room either side so the digits never touch the terminal edge.
+func bigTimeWidth(d time.Duration, f font) int {
+	return lipgloss.Width(bigTimeLines(d, f)[0]) + 4
+}
+
+// renderTime draws d in font f when it fits in width columns and falls
+// back to a plain bold clock otherwise.
+func renderTime(d time.Duration, f font, color lipgloss.TerminalColor, width int) string {
+	if width < bigTimeWidth(d, f) {
+		return lipgloss.NewStyle().Bold(true).Foreground(color).Render(clockString(d))
+	}
+	return renderBigTime(d, f, color)
+}
+
+// renderBigTime draws d in font f. Every row has the same width, with a
+// single space between glyphs and none trailing, so the clock centres
+// exactly; from an hour up it reads H:MM:SS like clockString.
+func renderBigTime(d time.Duration, f font, color lipgloss.TerminalColor) string {
+	fullBlock := strings.Join(bigTimeLines(d, f), "\n")
+	return lipgloss.NewStyle().Foreground(color).Render(fullBlock)
+}
+
+// bigTimeLines is the unstyled clock for d in font f, one string per row.
+func bigTimeLines(d time.Duration, f font) []string {
+	lines := make([]string, f.height)
+	for _, char := range clockString(d) {
+		block, ok := f.glyphs[char]
+		if !ok {
+			continue
+		}
+		for i := 0; i < f.height; i++ {
+			if lines[i] != "" {
+				lines[i] += " "
+			}
+			lines[i] += block[i]
+		}
+	}
+	return lines
+}
+
+// renderProgressBar draws how much of total has elapsed, given what is left.
+func renderProgressBar(left, total time.Duration, width int, color, empty lipgloss.TerminalColor) string {
+	if width <= 0 {
+		return ""
+	}
+	frac := 0.0
+	if total > 0 {
+		frac = 1 - float64(left)/float64(total)
+	}
+	frac = math.Max(0, math.Min(1, frac))
+	filled := int(frac * float64(width))
+	bar := lipgloss.NewStyle().Foreground(color).Render(strings.Repeat("█", filled))
+	return bar + lipgloss.NewStyle().Foreground(empty).Render(strings.Repeat("░", width-filled))
+}
+
+// phasePercent is how much of a phase of length total has passed with
+// left to go, rounded down so 100% only shows once it's over. Time added
+// or taken away with the arrow keys can't push it outside 0–100.
+func phasePercent(left, total time.Duration) int {
+	if total <= 0 {
+		return 0
+	}
+	return min(max(int(100*(total-left)/total), 0), 100)
+}
+
+// View draws the frame, led by a BEL when -bell has one waiting. The BEL
+// is zero width, so it doesn't move anything.
+func (m model) View() string {
+	frame := m.view()
+	if m.opts.bell != nil && m.opts.bell.Swap(false) {
+		frame = "\a" + frame
+	}
+	return frame
+}
+
+func (m model) view() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+	if m.opts.compact && m.state == stateRunning {
+		return m.viewCompact()
+	}
+	var s string
+	if m.state == stateSetup && !m.showHelp {
+		s = m.viewSetup()
+	} else if m.state == stateDone {
+		s = m.viewDone()
+	} else if m.showHelp {
+		s = m.viewHelp()
+	} else {
+		s = m.viewTimer()
+	}
+	if m.height <= 1 {
+		// No room to centre anything; show a single line so the frame
+		// doesn't scroll the terminal.
+		m.clicks.zones = nil
+		return strings.SplitN(s, "\n", 2)[0]
+	}
+	container := styleContainer
+	if time.Now().Before(m.flashUntil) {
+		container = container.Background(m.flashColor)
+	}
+	container = container.Width(m.width)
+	if !m.opts.inline {
+		container = container.Height(m.height)
+	}
+	frame := container.Render(s)
+	m.clicks.zones = nil
+	if m.opts.mouse && m.state == stateRunning && !m.showHelp {
+		m.clicks.locate(frame, m.timerButtons())
+	}
+	return frame
 }
 
 func (m model) viewSetup() string {
 	var b strings.Builder
-	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(colorBlue).Render("POMODORO SETUP") + "\n\n")
-	labels := []string{"Work Duration:", "Break Duration:", "Sessions:"}
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(m.theme.accent).Render("POMODORO SETUP") + "\n\n")
+	if m.streak != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(m.theme.longBreak).Render(m.streak) + "\n\n")
+	}
+	labels := []string{"Work Duration:", "Break Duration:", "Sessions:", "Long Break Duration:", "Task:"}
+	// Shrink the boxes on narrow terminals instead of letting them wrap.
+	box := styleInput.Width(min(40, max(m.width-2, 12)))
+	pad := 3
+	if box.GetWidth() < 30 {
+		pad = 1
+		box = box.Padding(0, pad)
+	}
 	for i := range m.inputs {
-		b.WriteString(lipgloss.NewStyle().Foreground(colorSubtle).Render(labels[i]) + "\n")
-		b.WriteString(styleInput.Render(m.inputs[i].View()) + "\n\n")
+		in := m.inputs[i]
+		in.Width = min(in.Width, box.GetWidth()-2*pad-2)
+		b.WriteString(lipgloss.NewStyle().Foreground(m.theme.subtle).Render(labels[i]) + "\n")
+		b.WriteString(box.BorderForeground(m.theme.subtle).Render(in.View()) + "\n")
+		if m.setupError != "" && m.setupErrorField == i {
+			b.WriteString(styleError.Render(m.setupError) + "\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(m.theme.accent).Render(m.setupPreview()) + "\n\n")
+	repeat := "off"
+	if m.repeat {
+		repeat = "on"
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(m.theme.subtle).Render("Repeat forever: "+repeat) + "\n")
+	if m.opts.canResume {
+		b.WriteString(lipgloss.NewStyle().Foreground(m.theme.subtle).Render("Unfinished session found. Run 'pomo resume' to pick it up.") + "\n")
 	}
-	b.WriteString(styleHelp.Render("\n[TAB] Switch  •  [ENTER] Start  •  [q] Quit"))
+	if m.wasReset {
+		note := "Timer reset."
+		if !m.opts.noLog {
+			note += " Logged sessions are kept."
+		}
+		b.WriteString(lipgloss.NewStyle().Foreground(m.theme.subtle).Render(note) + "\n")
+	}
+	b.WriteString(styleHelp.Foreground(m.theme.subtle).Render("\n[TAB] Switch  •  [↑/↓] Step (SHIFT: 5)  •  [ENTER] Start  •  [CTRL+R] Repeat  •  [F1] Keys  •  [q] Quit"))
 	return b.String()
 }
 
+// setupPreview summarises what the setup inputs would start, e.g.
+// "4 × (25m work + 5m break) ≈ 2h10m total", counting long breaks. Fields
+// that can't be read show as "—".
+func (m model) setupPreview() string {
+	dur := func(i int, def time.Duration) (time.Duration, string, bool) {
+		v := strings.TrimSpace(m.inputs[i].Value())
+		if v == "" {
+			return def, formatDuration(def), true
+		}
+		if d, ok := parseDuration(v); ok {
+			return d, formatDuration(d), true
+		}
+		return 0, "—", false
+	}
+	work, workStr, wok := dur(0, m.opts.work)
+	brk, brkStr, bok := dur(1, m.opts.brk)
+	long, _, lok := dur(3, m.opts.longBreak)
+	n, sessStr, sok := m.opts.sessions, strconv.Itoa(m.opts.sessions), true
+	budgetStr := ""
+	if v := strings.TrimSpace(m.inputs[2].Value()); v != "" {
+		count, budget, ok := parseSessions(v)
+		switch {
+		case !ok:
+			sessStr, sok = "—", false
+		case budget > 0 && wok:
+			n = sessionsFor(budget, work)
+			sessStr = strconv.Itoa(n)
+			budgetStr = formatDuration(budget) + " budget: "
+		case budget > 0:
+			sessStr, sok = "—", false
+		default:
+			n, sessStr = count, v
+		}
+	}
+	totalStr := "—"
+	if wok && bok && lok && sok {
+		p := m
+		p.workDuration, p.breakDuration, p.longBreakDuration = work, brk, long
+		p.sessionsTotal, p.cycle = n, 0
+		var total time.Duration
+		for i := 1; i <= n; i++ {
+			total += p.workFor(i) + p.breakAfter(i)
+		}
+		totalStr = formatDuration(total)
+	}
+	return fmt.Sprintf("%s%s × (%s work + %s break) ≈ %s total", budgetStr, sessStr, workStr, brkStr, totalStr)
+}
+
+// longBreakCountdown says how far off the next long break is, counting
+// the current work session, or "" when there's none to come this run.
+func (m model) longBreakCountdown() string {
+	if m.timerType != typeWork || m.countUp || m.interjected || m.longBreakDuration <= 0 {
+		return ""
+	}
+	n := m.cycle*m.sessionsTotal + m.currentSession
+	more := (m.longBreakEvery - n%m.longBreakEvery) % m.longBreakEvery
+	if !m.loops() && m.currentSession+more > m.sessionsTotal {
+		return ""
+	}
+	if more == 0 {
+		return "long break next!"
+	}
+	return fmt.Sprintf("%d sessions until long break", more+1)
+}
+
+// nextUp names what follows the current break: the next work session, or
+// DONE after the last one.
+func (m model) nextUp() string {
+	n := m.currentSession + 1
+	if n > m.sessionsTotal {
+		if !m.loops() {
+			return "DONE"
+		}
+		n = 1
+	}
+	return fmt.Sprintf("SESSION %d", n)
+}
+
 func (m model) viewTimer() string {
-	activeColor := colorBlue
-	modeStr := fmt.Sprintf("WORK SESSION %d/%d", m.currentSession, m.sessionsTotal)
+	activeColor := m.theme.work
+	total := strconv.Itoa(m.sessionsTotal)
+	if m.repeat {
+		total = "∞"
+	}
+	modeStr := fmt.Sprintf("WORK SESSION %d/%s", m.currentSession, total)
+	if len(m.schedule) > 0 {
+		step := m.schedule[m.scheduleIndex(m.currentSession)]
+		modeStr += fmt.Sprintf(" (%s/%s)", formatDuration(step.work), formatDuration(step.brk))
+	}
+	if m.timerType == typeWarmup {
+		activeColor = m.theme.accent
+		modeStr = "GET READY"
+	}
+	if m.overtime {
+		modeStr = fmt.Sprintf("OVERTIME %d/%s (%d/%d)", m.currentSession, total, m.overtimes, m.opts.maxOvertimes)
+	}
 	if m.timerType == typeBreak {
-		activeColor = colorYellow
-		modeStr = "BREAK TIME"
+		activeColor = m.theme.brk
+		// Breaks are numbered after the session they follow.
+		modeStr = fmt.Sprintf("BREAK %d/%s → %s", m.currentSession, total, m.nextUp())
+		if m.interjected {
+			modeStr = fmt.Sprintf("UNSCHEDULED BREAK → BACK TO SESSION %d", m.currentSession)
+		}
+		if m.snoozing {
+			modeStr = fmt.Sprintf("SNOOZE %d/%d", m.snoozes, m.opts.maxSnoozes)
+		}
+		if m.longBreak {
+			activeColor = m.theme.longBreak
+			modeStr = fmt.Sprintf("LONG BREAK %d/%s → %s", m.currentSession, total, m.nextUp())
+		}
+	}
+	shown := m.timeLeft
+	if m.countUp {
+		modeStr = fmt.Sprintf("STOPWATCH #%d", m.currentSession)
+		shown = m.timeElapsed
+	}
+	if m.inMicroBreak {
+		activeColor = m.theme.brk
+		modeStr = "MICRO BREAK"
+		shown = m.microLeft
+	}
+	// Focus mode drops everything but the clock, unless something needs
+	// an answer from the user.
+	if m.focusMode && !m.editingTask && !m.awaitingAck {
+		clock := renderTime(shown, m.font, activeColor, m.width)
+		if m.confirmingQuit {
+			clock += "\n\n" + lipgloss.NewStyle().Foreground(m.theme.subtle).Render("Quit? (y/n)")
+		}
+		return lipgloss.JoinVertical(lipgloss.Center, clock)
 	}
 	title := lipgloss.NewStyle().Bold(true).Foreground(activeColor).Render(modeStr)
-	asciiTimer := lipgloss.NewStyle().Margin(1, 0).Render(renderBigTime(m.timeLeft, activeColor))
+	if m.editingTask {
+		title += "\n" + styleInput.Width(36).Padding(0, 1).BorderForeground(activeColor).Render(m.taskInput.View())
+	} else if m.task != "" && m.timerType == typeWork && !m.inMicroBreak {
+		title += "\n" + lipgloss.NewStyle().Foreground(m.theme.subtle).Render("Working on: "+m.task)
+	} else if m.breakTip != "" && m.timerType == typeBreak && !m.snoozing {
+		title += "\n" + lipgloss.NewStyle().Foreground(m.theme.subtle).Render(m.breakTip)
+	}
+	if line := m.longBreakCountdown(); line != "" && !m.editingTask && !m.inMicroBreak {
+		title += "\n" + lipgloss.NewStyle().Foreground(m.theme.longBreak).Render(line)
+	}
+	barWidth := min(40, m.width-4)
+	if barWidth < 10 || m.countUp {
+		barWidth = 0
+	}
+	barLeft := m.timeLeft
+	if !m.paused && m.opts.tickEvery < time.Second {
+		// Finer ticks move the bar smoothly between whole seconds.
+		barLeft = max(m.endTime.Sub(m.lastTick), 0)
+	}
+	var asciiTimer, bar string
+	if m.opts.ring && !m.countUp && m.width >= ringWidth(shown, m.font) {
+		asciiTimer = lipgloss.NewStyle().Margin(1, 0).Render(renderRing(shown, m.font, barLeft, m.currentPhaseDuration, activeColor, m.theme.subtle))
+	} else {
+		asciiTimer = lipgloss.NewStyle().Margin(1, 0).Render(renderTime(shown, m.font, activeColor, m.width))
+		bar = renderProgressBar(barLeft, m.currentPhaseDuration, barWidth, activeColor, m.theme.subtle)
+	}
+	if m.showElapsed && !m.countUp && !m.inMicroBreak {
+		elapsed := max(m.currentPhaseDuration-m.timeLeft, 0)
+		line := fmt.Sprintf("elapsed %s / %s", clockString(elapsed), clockString(m.currentPhaseDuration))
+		bar = lipgloss.NewStyle().Foreground(m.theme.subtle).Render(line) + "\n" + bar
+	}
+	if m.opts.goal > 0 {
+		bar += "\n\n" + lipgloss.NewStyle().Foreground(m.theme.subtle).Render(renderGoal(m.goalProgress(), m.opts.goal))
+	}
+	if m.budget > 0 && !m.countUp {
+		bar += "\n\n" + lipgloss.NewStyle().Foreground(m.theme.subtle).Render(renderBudget(m.budgetFocused(), m.budget))
+	}
 	status := "RUNNING"
-	if m.paused {
+	if m.awaitingAck {
+		status = "⏰ TIME'S UP — press any key to continue"
+	} else if m.waitingToStart {
+		next := "work"
+		if m.timerType == typeBreak {
+			next = "break"
+		}
+		status = "READY — press SPACE to start " + next
+	} else if m.idlePaused {
+		status = "Auto-paused (idle) — press any key to resume"
+	} else if m.paused {
 		status = "PAUSED"
 	}
-	statusStr := lipgloss.NewStyle().Foreground(colorSubtle).Render(status)
-	help := styleHelp.Render("\n[SPACE] Pause  •  [s] Skip  •  [↑/↓] +/- 1m  •  [q] Quit")
-	return lipgloss.JoinVertical(lipgloss.Center, title, asciiTimer, statusStr, help)
+	if (status == "RUNNING" || status == "PAUSED") && !m.countUp && !m.inMicroBreak {
+		status += fmt.Sprintf(" — %d%%", phasePercent(m.timeLeft, m.currentPhaseDuration))
+	}
+	if m.limitsPauses() && !m.waitingToStart && !m.awaitingAck {
+		left := m.opts.maxPauses - m.pauses
+		switch left {
+		case 0:
+			status += "  •  no pauses left"
+		case 1:
+			status += "  •  1 pause left"
+		default:
+			status += fmt.Sprintf("  •  %d pauses left", left)
+		}
+	}
+	if m.canSnooze() {
+		status += "  •  [z] Snooze " + formatDuration(m.opts.snooze)
+	}
+	if m.canOvertime() {
+		status += "  •  [o] Overtime " + formatDuration(m.opts.overtime)
+	}
+	if m.mute != muteOff {
+		status += "  •  " + m.mute.String()
+	}
+	if m.note != "" && time.Now().Before(m.noteUntil) {
+		status += "  •  " + m.note
+	}
+	if m.confirmingQuit {
+		status = "Quit? (y/n)"
+	}
+	if m.inMicroBreak {
+		status += fmt.Sprintf("  •  work resumes with %s left", m.timeLeft)
+	}
+	if !m.countUp && !m.repeat {
+		eta := time.Now().Add(m.remainingTotal())
+		status += "\nFinishes at " + formatClock(eta, m.opts.clock12)
+	}
+	statusStr := lipgloss.NewStyle().Foreground(m.theme.subtle).Align(lipgloss.Center).Render(status)
+	helpText := "\n[SPACE] Pause  •  [s] Skip  •  [b] Back  •  [p] Break now  •  [↑/↓] +/- 1m\n[t] Task  •  [m] Mute  •  [r] Reset  •  [?] All keys  •  [q] Quit"
+	if m.countUp {
+		helpText = "\n[SPACE] Pause  •  [s] Save & restart\n[m] Mute  •  [r] Reset  •  [?] All keys  •  [q] Quit"
+	}
+	if m.timerType == typeWarmup {
+		helpText = "\n[SPACE] Pause  •  [s] Start now\n[m] Mute  •  [r] Reset  •  [q] Quit"
+	}
+	help := styleHelp.Foreground(m.theme.subtle).Align(lipgloss.Center).Render(helpText)
+	if m.opts.mouse && !m.editingTask && !m.confirmingQuit && !m.awaitingAck {
+		buttons := m.renderButtons(m.timerButtons(), activeColor)
+		return lipgloss.JoinVertical(lipgloss.Center, title, asciiTimer, bar, "", statusStr, "", buttons, help)
+	}
+	return lipgloss.JoinVertical(lipgloss.Center, title, asciiTimer, bar, "", statusStr, help)
+}
+
+// viewCompact is the whole timer on one line, e.g.
+// "WORK 2/4 ▸ 23:14 [running]".
+func (m model) viewCompact() string {
+	total := strconv.Itoa(m.sessionsTotal)
+	if m.repeat {
+		total = "∞"
+	}
+	label := fmt.Sprintf("WORK %d/%s", m.currentSession, total)
+	shown := m.timeLeft
+	switch {
+	case m.countUp:
+		label, shown = fmt.Sprintf("STOPWATCH #%d", m.currentSession), m.timeElapsed
+	case m.inMicroBreak:
+		label, shown = "MICRO BREAK", m.microLeft
+	case m.timerType == typeWarmup:
+		label = "GET READY"
+	case m.overtime:
+		label = fmt.Sprintf("OVERTIME %d/%s", m.currentSession, total)
+	case m.timerType == typeBreak && m.longBreak:
+		label = fmt.Sprintf("LONG BREAK %d/%s", m.currentSession, total)
+	case m.timerType == typeBreak && m.interjected:
+		label = "BREAK"
+	case m.timerType == typeBreak:
+		label = fmt.Sprintf("BREAK %d/%s", m.currentSession, total)
+	}
+	state := "running"
+	switch {
+	case m.confirmingQuit:
+		state = "quit? y/n"
+	case m.awaitingAck:
+		state = "time's up"
+	case m.waitingToStart:
+		state = "ready"
+	case m.idlePaused:
+		state = "idle"
+	case m.paused:
+		state = "paused"
+	}
+	line := fmt.Sprintf("%s ▸ %s [%s]", label, clockString(shown), state)
+	if m.note != "" && time.Now().Before(m.noteUntil) {
+		line += " " + m.note
+	}
+	color := m.theme.work
+	if m.timerType == typeBreak || m.inMicroBreak {
+		color = m.theme.brk
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(line)
+}
+
+// checkTerminal makes sure the timer screen has a terminal to draw on and
+// read keys from. Without this, bubbletea fails with a bare ioctl or
+// /dev/tty error.
+func checkTerminal() error {
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		return errors.New("standard output isn't a terminal; use -json to read the timer from a pipe, or -status-file for a status bar")
+	}
+	// Piped input is fine as long as keys can come from the terminal.
+	if !term.IsTerminal(os.Stdin.Fd()) && runtime.GOOS != "windows" {
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return errors.New("no terminal to read keys from; run pomo from a terminal, or use -json")
+		}
+		tty.Close()
+	}
+	return nil
 }
 
 func main() {
+	microBreak := flag.Duration("micro-break", 0, "length of each micro-break within a work session (e.g. 1m)")
+	microEvery := flag.Duration("micro-every", 0, "take a micro-break after this much work (e.g. 30m)")
+	pips := flag.Bool("pips", false, "beep softly at 3, 2 and 1 seconds before each boundary (same as -countdown-beep 3)")
+	countdown := flag.Int("countdown-beep", 0, "beep on each of the final N seconds of every phase")
+	warmup := flag.Int("warmup", 0, "count down this many seconds with GET READY before the first work session")
+	longEvery := flag.Int("long-every", 4, "take a long break after every N work sessions")
+	var noSound bool
+	flag.BoolVar(&noSound, "no-sound", false, "disable all sounds (desktop notifications still fire)")
+	flag.BoolVar(&noSound, "silent", false, "alias for -no-sound")
+	muteUntilBreak := flag.Bool("mute-until-break", false, "no sound or notifications until each work session ends")
+	noLog := flag.Bool("no-log", false, "don't record finished work sessions to the history log")
+	logFormat := flag.String("log-format", "jsonl", "write the history log as jsonl or csv (for spreadsheets); both are read back")
+	themeName := flag.String("theme", "default", "color theme: default, mono, solarized, dracula")
+	workColor := flag.String("work-color", "", "color for work phases, as an ANSI index (0-255) or hex (#rrggbb); overrides the theme")
+	breakColor := flag.String("break-color", "", "color for breaks, as an ANSI index or hex; overrides the theme")
+	accentColor := flag.String("accent-color", "", "color for the setup screen and help highlights, as an ANSI index or hex")
+	nightTheme := flag.String("night-theme", "", "color theme to switch to at night; -theme is used by day")
+	dayAt := flag.String("day-at", "07:00", "time of day -theme takes over from -night-theme")
+	nightAt := flag.String("night-at", "19:00", "time of day -night-theme takes over from -theme")
+	location := flag.String("location", "", "switch themes at sunrise and sunset here instead, as LAT,LON (e.g. 52.52,13.40)")
+	stopwatch := flag.Bool("stopwatch", false, "count up instead of down, with no automatic finish")
+	notifierName := flag.String("notifier", "beep", "how alerts are delivered: beep (sound and desktop notification) or silent")
+	webhook := flag.String("webhook", "", "also POST each alert as JSON to this URL")
+	ntfy := flag.String("ntfy", "", "also publish each alert to this ntfy topic (a name on ntfy.sh, or a full topic URL)")
+	bell := flag.Bool("bell", false, "also ring the terminal bell when a phase ends")
+	icon := flag.String("icon", "", "image to show in desktop notifications (default: bundled tomato)")
+	autoWork := flag.Bool("auto-start-work", true, "start work sessions automatically after a break")
+	autoBreak := flag.Bool("auto-start-break", true, "start breaks automatically after a work session")
+	soundPath := flag.String("sound", "", "sound file to play at the end of each phase (WAV on Windows)")
+	soundTheme := flag.String("sound-theme", "", "play a bundled beep pattern instead of the default alert (see 'pomo sounds')")
+	beepFreq := flag.Float64("beep-freq", 0, "tone of the fallback beep in Hz, 100-5000 (default: the system's)")
+	beepDuration := flag.Int("beep-duration", 0, "length of the fallback beep in ms, 50-5000 (default: the system's)")
+	volume := flag.Int("volume", 100, "volume of the -sound file in percent, where the player supports it (paplay, afplay)")
+	persistAlarm := flag.Bool("persist-alarm", false, "repeat the alarm every few seconds after a phase ends until a key is pressed")
+	presetName := flag.String("preset", "", "use a preset: classic, long, short, 52-17 (see 'pomo presets')")
+	task := flag.String("task", "", "label for the task you're working on, saved with each session")
+	repeat := flag.Bool("repeat", false, "cycle through the sessions forever instead of quitting")
+	startPaused := flag.Bool("start-paused", false, "start the first session paused; press SPACE to begin")
+	edit := flag.Bool("edit", false, "open the setup screen filled in from the arguments and flags, instead of starting")
+	onComplete := flag.String("on-complete", "quit", "what to do after the last session: quit, stay (keep the summary up until a key is pressed) or loop (start the sessions over)")
+	serve := flag.String("serve", "", "serve the timer state as JSON over HTTP on this address (e.g. :8080)")
+	statusPath := flag.String("status-file", "", "keep a one-line timer summary in this file for status bars")
+	ratio := flag.String("ratio", "", "size breaks from the work length, e.g. 5:1 for a fifth (overrides the break duration)")
+	schedule := flag.String("schedule", "", "per-session WORK/BREAK lengths, e.g. \"50/10,25/5,15/3\" (the last repeats)")
+	idle := flag.String("pause-on-idle", "", "pause work sessions after this long without a key press (minutes, or e.g. 90s)")
+	goal := flag.Int("goal", 0, "daily goal: track progress toward N pomodoros per day")
+	skipNoCount := flag.Bool("skip-no-count", false, "leave skipped work sessions out of the summary, a time budget and -max-focus")
+	snooze := flag.Duration("snooze", 2*time.Minute, "how much longer z makes a break that just ended (0 disables)")
+	maxSnoozes := flag.Int("max-snoozes", 2, "how many times each break can be snoozed")
+	overtime := flag.Duration("overtime", 10*time.Minute, "how much more work o adds to a session that just ended, before its break (0 disables)")
+	maxOvertimes := flag.Int("max-overtimes", 2, "how many overtimes can run back to back")
+	maxPauses := flag.Int("max-pauses", 0, "how many times SPACE can pause each work session (0 means no limit)")
+	onWork := flag.String("on-work", "", "shell command to run whenever a work session begins")
+	onBreak := flag.String("on-break", "", "shell command to run whenever a break begins")
+	tickEvery := flag.Duration("tick", time.Second, "how often to redraw; e.g. 250ms for a smoother progress bar (min 100ms)")
+	workOnly := flag.Bool("work-only", false, "no breaks: a single work session unless a session count is given")
+	clock12 := flag.Bool("12h", false, "show times of day on a 12-hour clock (default follows the locale)")
+	clock24 := flag.Bool("24h", false, "show times of day on a 24-hour clock")
+	noTips := flag.Bool("no-tips", false, "don't suggest something to do (stretch, drink water...) during breaks")
+	compact := flag.Bool("compact", false, "show the timer as a single line instead of full screen")
+	var inline bool
+	flag.BoolVar(&inline, "inline", false, "draw in the normal screen instead of the alternate one, so the last frame stays in scrollback")
+	flag.BoolVar(&inline, "no-altscreen", false, "alias for -inline")
+	quiet := flag.Bool("quiet", false, "print nothing when the timer exits: no summary, and failures only show in the exit status")
+	noMouse := flag.Bool("no-mouse", false, "don't draw clickable Pause/Skip buttons or capture the mouse")
+	fontName := flag.String("font", "block", "font for the big clock: block, or thin (three rows, for small terminals)")
+	style := flag.String("style", "bar", "how to show progress: bar, or ring (drawn around the clock)")
+	plainGlyphs := flag.Bool("plain-glyphs", false, "the terminal font lacks half-block characters; -style ring falls back to the bar")
+	maxFocus := flag.Duration("max-focus", 0, "end the run with a firm break reminder once this much work is done (e.g. 2h), checked between sessions")
+	until := flag.String("until", "", "don't start new work sessions after this time of day, e.g. 18:00")
+	jsonOut := flag.Bool("json", false, "run without the TUI, writing timer events to stdout as JSON lines")
+	jsonTicks := flag.Duration("json-ticks", 0, "with -json, also write a tick event this often (e.g. 10s)")
+	showVersion := flag.Bool("version", false, "print the version and build info, then exit")
+	flag.Usage = usage
 	flag.Parse()
-	args := flag.Args()
-	var w, b, s string
+	if *showVersion {
+		printVersion(os.Stdout)
+		return
+	}
+	cmd, cmdFlags, args, err := parseCommand(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+	sound := alertSound{path: *soundPath, freq: *beepFreq, duration: *beepDuration, volume: *volume}.clamped()
+	if *soundTheme != "" {
+		st, ok := findSoundTheme(*soundTheme)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown sound theme %q. Available themes:\n", *soundTheme)
+			printSoundThemes(os.Stderr)
+			os.Exit(1)
+		}
+		sound.tones = st.tones
+	}
+	use12h := localeUses12h()
+	if *clock12 {
+		use12h = true
+	} else if *clock24 {
+		use12h = false
+	}
+	if cmd.run != nil {
+		env := commandEnv{sound: sound, icon: resolveIcon(*icon), clock12: use12h}
+		if err := cmd.run(env, args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	resuming := cmd.name == "resume"
+	var w, b, s, l string
 	if len(args) > 0 {
 		w = args[0]
 	}
@@ -397,8 +2784,307 @@ func main() {
 	if len(args) > 2 {
 		s = args[2]
 	}
-	p := tea.NewProgram(initialModel(w, b, s), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error: %v", err)
+	if len(args) > 3 {
+		l = args[3]
+	}
+	// Flags given on the command line win over POMO_* variables, which win
+	// over the config file, which wins over the built-in defaults.
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: ignoring config file: %v\n", err)
+	}
+	cfg, warnings := applyEnv(cfg, os.Getenv)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	cmdFlags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["theme"] {
+		*themeName = cfg.theme
+	}
+	if !set["no-sound"] && !set["silent"] {
+		noSound = !cfg.sound
+	}
+	if *presetName != "" {
+		p, ok := findPreset(*presetName)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown preset %q. Available presets:\n", *presetName)
+			printPresets(os.Stderr)
+			os.Exit(1)
+		}
+		cfg.work, cfg.brk, cfg.sessions = p.work, p.brk, p.sessions
+	}
+
+	var steps []phase
+	if *schedule != "" {
+		if steps, err = parseSchedule(*schedule); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var cutoff time.Duration
+	if *until != "" {
+		if cutoff, err = parseCutoff(*until); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		now := time.Now()
+		if now.Sub(startOfDay(now)) >= cutoff {
+			fmt.Fprintf(os.Stderr, "It's already past %s; not starting. Rest up for tomorrow.\n", *until)
+			os.Exit(1)
+		}
+	}
+
+	var light daylight
+	if *nightTheme != "" {
+		var okDay, okNight bool
+		light.dayAt, okDay = parseTimeOfDay(*dayAt)
+		light.nightAt, okNight = parseTimeOfDay(*nightAt)
+		if !okDay || !okNight || light.dayAt == light.nightAt {
+			fmt.Fprintf(os.Stderr, "Error: invalid -day-at %q / -night-at %q: want two different HH:MM times\n", *dayAt, *nightAt)
+			os.Exit(1)
+		}
+		if *location != "" {
+			if light.lat, light.lon, err = parseLocation(*location); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			light.located = true
+		}
+	} else if *location != "" || set["day-at"] || set["night-at"] {
+		fmt.Fprintln(os.Stderr, "Error: -location, -day-at and -night-at need -night-theme")
+		os.Exit(1)
+	}
+
+	var idleAfter time.Duration
+	if *idle != "" {
+		d, ok := parseDuration(*idle)
+		if !ok || d <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: invalid -pause-on-idle %q\n", *idle)
+			os.Exit(1)
+		}
+		idleAfter = d
+	}
+
+	if _, ok := fonts[strings.ToLower(*fontName)]; !ok {
+		fmt.Fprintf(os.Stderr, "Error: invalid -font %q: want block or thin\n", *fontName)
+		os.Exit(1)
+	}
+
+	if *logFormat != "jsonl" && *logFormat != "csv" {
+		fmt.Fprintf(os.Stderr, "Error: invalid -log-format %q: want jsonl or csv\n", *logFormat)
+		os.Exit(1)
+	}
+
+	switch *onComplete {
+	case "quit", "stay", "loop":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid -on-complete %q: want quit, stay or loop\n", *onComplete)
+		os.Exit(1)
+	}
+
+	switch *style {
+	case "bar", "ring":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid -style %q: want bar or ring\n", *style)
+		os.Exit(1)
+	}
+
+	var breakRatio float64
+	if *ratio != "" {
+		if breakRatio, err = parseRatio(*ratio); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	mute := muteOff
+	if *muteUntilBreak {
+		mute = muteWork
+	}
+	if noSound {
+		mute = muteAll
+	}
+
+	*tickEvery = min(max(*tickEvery, 100*time.Millisecond), time.Second)
+
+	if *workOnly {
+		cfg.brk, cfg.longBreak = 0, 0
+		b, l = "0", "0"
+		if s == "" {
+			cfg.sessions = 1
+		}
+	}
+
+	if *pips {
+		*countdown = max(*countdown, 3)
+	}
+
+	for _, c := range []struct {
+		name  string
+		value *string
+	}{{"work-color", workColor}, {"break-color", breakColor}, {"accent-color", accentColor}} {
+		if *c.value != "" && !validColor(*c.value) {
+			fmt.Fprintf(os.Stderr, "warning: ignoring -%s %q: want an ANSI index (0-255) or hex like #ff8800\n", c.name, *c.value)
+			*c.value = ""
+		}
+	}
+
+	notifier, err := newNotifier(*notifierName, sound, resolveIcon(*icon))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// Push backends and the bell go alongside the local one, not instead
+	// of it.
+	var ring *atomic.Bool
+	if *webhook != "" || *ntfy != "" || *bell {
+		all := multiNotifier{notifier}
+		if *bell {
+			ring = new(atomic.Bool)
+			all = append(all, BellNotifier{ring: ring})
+		}
+		if *webhook != "" {
+			all = append(all, webhookNotifier(*webhook))
+		}
+		if *ntfy != "" {
+			all = append(all, ntfyNotifier(*ntfy))
+		}
+		notifier = all
+	}
+
+	opts := options{
+		work:        cfg.work,
+		brk:         cfg.brk,
+		longBreak:   cfg.longBreak,
+		sessions:    cfg.sessions,
+		microBreak:  *microBreak,
+		microEvery:  *microEvery,
+		countdown:   *countdown,
+		warmup:      time.Duration(max(*warmup, 0)) * time.Second,
+		longEvery:   *longEvery,
+		mute:        mute,
+		noLog:       *noLog,
+		logFormat:   *logFormat,
+		theme:       *themeName,
+		nightTheme:  *nightTheme,
+		daylight:    light,
+		font:        *fontName,
+		workColor:   *workColor,
+		breakColor:  *breakColor,
+		accentColor: *accentColor,
+		stopwatch:   *stopwatch,
+		repeat:      *repeat,
+		startPaused: *startPaused,
+		onComplete:  *onComplete,
+		edit:        *edit,
+		task:        *task,
+
+		autoStartWork:  *autoWork,
+		autoStartBreak: *autoBreak,
+		persistAlarm:   *persistAlarm,
+		notifier:       notifier,
+		bell:           ring,
+		compact:        *compact,
+		noTips:         *noTips,
+		clock12:        use12h,
+		snooze:         *snooze,
+		maxSnoozes:     *maxSnoozes,
+		overtime:       *overtime,
+		maxOvertimes:   *maxOvertimes,
+		maxPauses:      *maxPauses,
+		tickEvery:      *tickEvery,
+		onWork:         *onWork,
+		onBreak:        *onBreak,
+		ratio:          breakRatio,
+		goal:           *goal,
+		skipNoCount:    *skipNoCount,
+		idleAfter:      idleAfter,
+		until:          cutoff,
+		maxFocus:       *maxFocus,
+		mouse:          !*noMouse && !*compact && !inline,
+		inline:         inline,
+		schedule:       steps,
+		ring:           *style == "ring" && !*plainGlyphs,
+	}
+	// Registered first so it runs last, after the cleanups below.
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+	if *serve != "" {
+		opts.status = &sharedStatus{}
+		srv, err := startStatusServer(*serve, opts.status)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer stopStatusServer(srv)
+	}
+	if *statusPath != "" {
+		opts.statusFile = &statusFile{path: *statusPath}
+		defer opts.statusFile.write("idle")
+	}
+	if path, err := statePath(); err == nil {
+		opts.stateFile = &stateFile{path: path}
+	}
+	saved, stateErr := loadState()
+	if stateErr == nil {
+		stateErr = saved.resumable(time.Now(), opts.clock12)
+	}
+	if resuming && stateErr != nil {
+		if errors.Is(stateErr, fs.ErrNotExist) {
+			stateErr = errNothingToResume
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", stateErr)
+		os.Exit(1)
+	}
+	opts.canResume = stateErr == nil && !resuming
+	m := initialModel(w, b, s, l, opts)
+	if resuming {
+		m = m.restore(saved, time.Now())
+	}
+	if opts.status != nil {
+		opts.status.set(m.snapshot())
+	}
+	if opts.statusFile != nil {
+		opts.statusFile.write(m.statusLine())
+	}
+	if *jsonOut {
+		runJSON(m, os.Stdout, *jsonTicks)
+		return
+	}
+	if err := checkTerminal(); err != nil {
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		exitCode = 1
+		return
+	}
+	var progOpts []tea.ProgramOption
+	if !*compact && !inline {
+		progOpts = append(progOpts, tea.WithAltScreen())
+	}
+	// Click positions are worked out against a full-screen frame, so -mouse
+	// is off with -compact and -inline.
+	if opts.mouse {
+		progOpts = append(progOpts, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(m, progOpts...)
+	defer forwardSignals(p)()
+	final, err := p.Run()
+	if err != nil {
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		exitCode = 1
+		return
+	}
+	if fm, ok := final.(model); ok && fm.completed && !*quiet {
+		fmt.Println(fm.summary())
 	}
 }
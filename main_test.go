@@ -0,0 +1,449 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"25", 25 * time.Minute, true},
+		{"25s", 25 * time.Second, true},
+		{"1.5", 90 * time.Second, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"1H30M", 90 * time.Minute, true},
+		{"1h30", 90 * time.Minute, true},
+		{"2x25", 50 * time.Minute, true},
+		{"3x10m", 30 * time.Minute, true},
+		{"0", 0, true},
+		{"", 0, false},
+		{"abc", 0, false},
+		{"25 minutes", 0, false},
+		{"1h30x", 0, false},
+		{"0x25", 0, false},
+		{"-5", 0, false},
+		{"-5m", 0, false},
+		{"-2x25", 0, false},
+		{"inf", 0, false},
+		{"-inf", 0, false},
+		{"nan", 0, false},
+		{"1e30", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseDuration(tt.in)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parseDuration(%q) = %s, %t; want %s, %t", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseDurationInputFallsBack(t *testing.T) {
+	def := 25 * time.Minute
+	for _, in := range []string{"", "  ", "garbage", "-5", "nan"} {
+		if got := parseDurationInput(in, def); got != def {
+			t.Errorf("parseDurationInput(%q) = %s, want the default %s", in, got, def)
+		}
+	}
+	if got := parseDurationInput(" 50 ", def); got != 50*time.Minute {
+		t.Errorf("parseDurationInput(%q) = %s, want 50m", " 50 ", got)
+	}
+}
+
+// glyphWidth is how wide the big clock for s should be in font f: its
+// glyphs plus one space between each.
+func glyphWidth(s string, f font) int {
+	w := len(s) - 1
+	for _, c := range s {
+		w += lipgloss.Width(f.glyphs[c][0])
+	}
+	return w
+}
+
+func TestBigTimeHourBoundary(t *testing.T) {
+	f := fonts["block"]
+	tests := []struct {
+		d     time.Duration
+		clock string
+	}{
+		{59*time.Minute + 59*time.Second, "59:59"},
+		{time.Hour, "1:00:00"},
+		{time.Hour + time.Second, "1:00:01"},
+	}
+	for _, tt := range tests {
+		if got := clockString(tt.d); got != tt.clock {
+			t.Errorf("clockString(%s) = %q, want %q", tt.d, got, tt.clock)
+		}
+		want := glyphWidth(tt.clock, f)
+		for i, line := range strings.Split(renderBigTime(tt.d, f, lipgloss.Color("1")), "\n") {
+			if w := lipgloss.Width(line); w != want {
+				t.Errorf("%s row %d is %d wide, want %d", tt.clock, i, w, want)
+			}
+		}
+	}
+}
+
+// testModel starts a 25/5 run of four sessions with nothing written to
+// disk and no sound.
+func testModel(t *testing.T, opts options) model {
+	t.Helper()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	opts.noLog, opts.mute = true, muteAll
+	opts.autoStartWork, opts.autoStartBreak = true, true
+	return initialModel("25m", "5m", "4", "15m", opts)
+}
+
+// send runs msg through Update, as the program would.
+func send(m model, msg tea.Msg) model {
+	next, _ := m.Update(msg)
+	return next.(model)
+}
+
+// tickAt is a live tick landing at when.
+func tickAt(m model, when time.Time) tickMsg {
+	return tickMsg{id: m.timerID, at: when}
+}
+
+func TestPauseToggleKeepsOneTickLoop(t *testing.T) {
+	m := testModel(t, options{})
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	stale := tickAt(m, m.endTime.Add(-24*time.Minute))
+
+	m = send(m, space)
+	if !m.paused {
+		t.Fatal("SPACE didn't pause")
+	}
+	m = send(m, space)
+	if m.paused {
+		t.Fatal("second SPACE didn't resume")
+	}
+
+	// The tick scheduled before the toggle must be ignored.
+	left := m.timeLeft
+	m = send(m, stale)
+	if m.timeLeft != left {
+		t.Fatalf("stale tick moved the clock from %s to %s", left, m.timeLeft)
+	}
+
+	start := m.endTime.Add(-m.timeLeft)
+	for i := 1; i <= 5; i++ {
+		m = send(m, tickAt(m, start.Add(time.Duration(i)*time.Second)))
+		if want := left - time.Duration(i)*time.Second; m.timeLeft != want {
+			t.Fatalf("after tick %d: timeLeft = %s, want %s", i, m.timeLeft, want)
+		}
+	}
+}
+
+func TestBigTimeTwoHours(t *testing.T) {
+	d, ok := parseDuration("120")
+	if !ok || d != 2*time.Hour {
+		t.Fatalf(`parseDuration("120") = %s, %t`, d, ok)
+	}
+	for name, f := range fonts {
+		rows := strings.Split(renderBigTime(d, f, lipgloss.Color("1")), "\n")
+		if len(rows) != f.height {
+			t.Fatalf("%s: %d rows, want %d", name, len(rows), f.height)
+		}
+		want := glyphWidth("2:00:00", f)
+		for i, line := range rows {
+			if w := lipgloss.Width(line); w != want {
+				t.Errorf("%s: row %d is %d wide, want %d", name, i, w, want)
+			}
+		}
+	}
+}
+
+// recorder is a Notifier that keeps what it was asked to do.
+type recorder struct {
+	sounds *int
+	alerts *[]Alert
+}
+
+func newRecorder() recorder { return recorder{new(int), new([]Alert)} }
+
+func (r recorder) Sound()         { *r.sounds++ }
+func (recorder) Pip()             {}
+func (r recorder) Notify(a Alert) { *r.alerts = append(*r.alerts, a) }
+
+func TestTickAfterSleepFinishesPhase(t *testing.T) {
+	rec := newRecorder()
+	m := testModel(t, options{notifier: rec, logFormat: "jsonl"})
+	// Sounds go to the recorder, and the session goes to the log.
+	m.mute, m.opts.noLog = muteOff, false
+	m = send(m, tickAt(m, m.endTime.Add(-20*time.Minute)))
+	if m.timerType != typeWork || m.timeLeft != 20*time.Minute {
+		t.Fatalf("before the jump: %s left of phase %d", m.timeLeft, m.timerType)
+	}
+
+	// The machine sleeps through the end of the session; the first tick
+	// after waking lands well past endTime.
+	m = send(m, tickAt(m, m.endTime.Add(10*time.Minute)))
+	if m.timerType != typeBreak || m.currentSession != 1 {
+		t.Fatalf("after the jump: phase %d session %d, want the first break", m.timerType, m.currentSession)
+	}
+	if m.timeLeft != 5*time.Minute {
+		t.Errorf("break starts with %s left, want 5m", m.timeLeft)
+	}
+	// handleTimerFinish is what flashes the screen.
+	if !m.flashUntil.After(time.Now()) {
+		t.Error("the end of the session wasn't announced")
+	}
+	if *rec.sounds != 1 {
+		t.Errorf("alert sounded %d times, want 1", *rec.sounds)
+	}
+	if len(*rec.alerts) != 1 || (*rec.alerts)[0].Phase != "break" {
+		t.Errorf("notifications = %+v, want one for the break", *rec.alerts)
+	}
+
+	// The sleep after the deadline isn't focus time.
+	if m.focusedTotal != 25*time.Minute {
+		t.Errorf("focusedTotal = %s, want 25m", m.focusedTotal)
+	}
+	recs, err := readHistory()
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("history = %+v, %v; want one record", recs, err)
+	}
+	if recs[0].Duration != 25*60 {
+		t.Errorf("logged duration_seconds = %d, want %d", recs[0].Duration, 25*60)
+	}
+}
+
+func TestArrowKeysOnThirtySecondPhase(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	m := initialModel("30s", "5m", "4", "15m", options{noLog: true, mute: muteAll, autoStartWork: true, autoStartBreak: true})
+	keyDown := tea.KeyMsg{Type: tea.KeyDown}
+	keyUp := tea.KeyMsg{Type: tea.KeyUp}
+	minus := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'-'}}
+
+	check := func(step string) {
+		t.Helper()
+		if m.timeLeft < 0 {
+			t.Fatalf("%s: timeLeft went negative: %s", step, m.timeLeft)
+		}
+		if p := phasePercent(m.timeLeft, m.currentPhaseDuration); p < 0 || p > 100 {
+			t.Errorf("%s: percentage %d is outside 0–100", step, p)
+		}
+		bar := []rune(ansi.Strip(renderProgressBar(m.timeLeft, m.currentPhaseDuration, 20, lipgloss.Color("1"), lipgloss.Color("2"))))
+		if len(bar) != 20 {
+			t.Errorf("%s: bar is %d cells, want 20", step, len(bar))
+		}
+	}
+
+	for range 3 {
+		m = send(m, keyDown)
+	}
+	if m.timeLeft != 30*time.Second {
+		t.Errorf("↓ took a 30s phase to %s; it should stay put", m.timeLeft)
+	}
+	check("↓ at 30s")
+
+	start := m.endTime.Add(-m.timeLeft)
+	m = send(m, tickAt(m, start.Add(20*time.Second)))
+	m = send(m, keyDown)
+	if m.timeLeft != 10*time.Second {
+		t.Errorf("↓ with 10s left gave %s, want 10s", m.timeLeft)
+	}
+	check("↓ at 10s")
+
+	m = send(m, keyUp)
+	if m.timeLeft != 70*time.Second {
+		t.Errorf("↑ with 10s left gave %s, want 1m10s", m.timeLeft)
+	}
+	check("↑")
+	m = send(m, keyDown)
+	m = send(m, keyDown)
+	check("↓ after ↑")
+
+	m = send(m, minus)
+	if m.workDuration != 30*time.Second {
+		t.Errorf("- shrank the 30s work length to %s", m.workDuration)
+	}
+}
+
+func TestFontsRenderZero(t *testing.T) {
+	for _, name := range []string{"block", "thin"} {
+		f := fontByName(name)
+		// A glyph short of f.height rows would panic in bigTimeLines.
+		for _, c := range "0123456789:" {
+			if rows := len(f.glyphs[c]); rows != f.height {
+				t.Errorf("%s: glyph %q has %d rows, want %d", name, c, rows, f.height)
+			}
+		}
+		want := glyphWidth("00:00", f)
+		for i, line := range bigTimeLines(0, f) {
+			if w := lipgloss.Width(line); w != want {
+				t.Errorf("%s: 00:00 row %d is %d wide, want %d", name, i, w, want)
+			}
+		}
+	}
+}
+
+func TestParseConfigRejectsZeroWork(t *testing.T) {
+	_, err := parseConfig(strings.NewReader("# defaults\nwork = 0\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("work = 0 gave %v, want a line 2 error", err)
+	}
+	if cfg, err := parseConfig(strings.NewReader("break = 0\n")); err != nil || cfg.brk != 0 {
+		t.Errorf("break = 0 gave %s, %v; a zero break is allowed", cfg.brk, err)
+	}
+}
+
+func TestApplyEnvIgnoresZeroWork(t *testing.T) {
+	env := map[string]string{"POMO_WORK": "0", "POMO_BREAK": "0"}
+	cfg, warnings := applyEnv(defaultConfig(), func(k string) string { return env[k] })
+	if cfg.work != defaultConfig().work {
+		t.Errorf("POMO_WORK=0 set work to %s", cfg.work)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "POMO_WORK") {
+		t.Errorf("warnings = %q, want one about POMO_WORK", warnings)
+	}
+	if cfg.brk != 0 {
+		t.Errorf("POMO_BREAK=0 gave a %s break, want 0", cfg.brk)
+	}
+}
+
+func TestQuitDuringPersistentAlarm(t *testing.T) {
+	m := testModel(t, options{persistAlarm: true})
+	m = send(m, tickAt(m, m.endTime))
+	if !m.awaitingAck {
+		t.Fatal("the end of the session didn't ring the alarm")
+	}
+	m = send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	if m.awaitingAck || !m.confirmingQuit {
+		t.Fatalf("q left awaitingAck=%t confirmingQuit=%t; want the quit prompt", m.awaitingAck, m.confirmingQuit)
+	}
+	if !m.paused || !m.waitingToStart {
+		t.Error("the break started behind the quit prompt")
+	}
+}
+
+func TestTogglePhaseLogsCutShortWork(t *testing.T) {
+	m := testModel(t, options{logFormat: "jsonl"})
+	m.opts.noLog = false
+	key := func(s string) tea.KeyMsg {
+		if s == "tab" {
+			return tea.KeyMsg{Type: tea.KeyTab}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+	m = send(m, tickAt(m, m.endTime.Add(-15*time.Minute)))
+	m = send(m, key("i"))
+	m = send(m, key("i"))
+	m = send(m, key("tab"))
+	if m.timerType != typeBreak {
+		t.Fatalf("TAB left phase %d, want a break", m.timerType)
+	}
+	if m.interruptions != 0 {
+		t.Errorf("%d interruptions carried into the break", m.interruptions)
+	}
+	recs, err := readHistory()
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("history = %+v, %v; want one record", recs, err)
+	}
+	r := recs[0]
+	if r.Duration != 10*60 || !r.Skipped || r.Interruptions != 2 {
+		t.Errorf("logged %+v, want 600s, skipped, 2 interruptions", r)
+	}
+	if m.focusedTotal != 10*time.Minute {
+		t.Errorf("focusedTotal = %s, want 10m", m.focusedTotal)
+	}
+}
+
+func TestParseCutoff(t *testing.T) {
+	if d, err := parseCutoff("18:30"); err != nil || d != 18*time.Hour+30*time.Minute {
+		t.Errorf(`parseCutoff("18:30") = %s, %v`, d, err)
+	}
+	for _, in := range []string{"00:00", "0:00", "24:00", "6pm"} {
+		if _, err := parseCutoff(in); err == nil {
+			t.Errorf("parseCutoff(%q) accepted it", in)
+		}
+	}
+}
+
+func TestRestartWithHelpOpen(t *testing.T) {
+	m := testModel(t, options{})
+	m = send(m, tickAt(m, m.endTime.Add(-20*time.Minute)))
+	m = send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'?'}})
+	m = send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'R'}})
+	if !m.showHelp {
+		t.Error("R closed the help overlay")
+	}
+	if m.timeLeft != 25*time.Minute {
+		t.Errorf("R with help open left %s, want the full 25m", m.timeLeft)
+	}
+}
+
+func TestNextReadsStateFile(t *testing.T) {
+	m := testModel(t, options{})
+	var out strings.Builder
+	if err := runNext(&out, time.Now()); err != errNoActive {
+		t.Fatalf("with no state file: %v, want %v", err, errNoActive)
+	}
+
+	path, err := statePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &stateFile{path: path}
+	f.update(m)
+	st, err := loadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runNext(&out, st.SavedAt.Add(5*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "20m until break\n" {
+		t.Errorf("pomo next printed %q, want %q", got, "20m until break\n")
+	}
+
+	st.OnBreak, st.Paused, st.TimeLeft = true, true, 90*time.Second
+	if got := st.next(st.SavedAt.Add(time.Hour)); got != "1m30s until work (paused)" {
+		t.Errorf("paused break: %q", got)
+	}
+}
+
+func TestNightThemeFollowsClock(t *testing.T) {
+	at := func(hhmm string) time.Time {
+		d, _ := parseTimeOfDay(hhmm)
+		return startOfDay(time.Now()).Add(d)
+	}
+	tests := []struct {
+		dayAt, nightAt, now string
+		night               bool
+	}{
+		{"07:00", "19:00", "06:59", true},
+		{"07:00", "19:00", "07:00", false},
+		{"07:00", "19:00", "18:59", false},
+		{"07:00", "19:00", "19:00", true},
+		{"07:00", "19:00", "00:00", true},
+		// A night shift: the day theme runs over midnight.
+		{"18:00", "06:00", "23:00", false},
+		{"18:00", "06:00", "05:59", false},
+		{"18:00", "06:00", "12:00", true},
+	}
+	for _, tt := range tests {
+		dayAt, _ := parseTimeOfDay(tt.dayAt)
+		nightAt, _ := parseTimeOfDay(tt.nightAt)
+		d := daylight{dayAt: dayAt, nightAt: nightAt}
+		if got := d.isNight(at(tt.now)); got != tt.night {
+			t.Errorf("day %s night %s at %s: isNight = %t, want %t", tt.dayAt, tt.nightAt, tt.now, got, tt.night)
+		}
+	}
+
+	m := testModel(t, options{theme: "solarized", nightTheme: "dracula", daylight: daylight{dayAt: 7 * time.Hour, nightAt: 19 * time.Hour}})
+	if got := m.themeAt(at("12:00")); got != themeByName("solarized") {
+		t.Error("the day theme isn't used at noon")
+	}
+	if got := m.themeAt(at("22:00")); got != themeByName("dracula") {
+		t.Error("the night theme isn't used at 22:00")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// button is a clickable box on the timer screen. A click does whatever
+// its key does.
+type button struct {
+	key   tea.KeyMsg
+	label string
+}
+
+// zone is where View last drew a button, in screen cells.
+type zone struct {
+	key        tea.KeyMsg
+	x, y, w, h int
+}
+
+// clickZones holds the zones of the last frame. View can't change the
+// model, so every copy shares this through a pointer.
+type clickZones struct {
+	zones []zone
+}
+
+var (
+	keySpace = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	keySkip  = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}}
+)
+
+// timerButtons are the buttons for the current state of the timer.
+func (m model) timerButtons() []button {
+	pause := "⏸ Pause"
+	if m.paused {
+		pause = "▶ Resume"
+	}
+	skip := "⏭ Skip"
+	if m.countUp {
+		skip = "⏭ Lap"
+	}
+	return []button{{keySpace, pause}, {keySkip, skip}}
+}
+
+// buttonStyle is the box drawn around each button's label.
+func (m model) buttonStyle(color lipgloss.TerminalColor) lipgloss.Style {
+	return lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(color).Foreground(color).Padding(0, 1)
+}
+
+// renderButtons draws the buttons side by side.
+func (m model) renderButtons(buttons []button, color lipgloss.TerminalColor) string {
+	boxes := make([]string, 0, 2*len(buttons))
+	for i, b := range buttons {
+		if i > 0 {
+			boxes = append(boxes, "  ")
+		}
+		boxes = append(boxes, m.buttonStyle(color).Render(b.label))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, boxes...)
+}
+
+// locate finds each button in a finished frame by the text of its middle
+// row, which is easier than following the centring maths.
+func (c *clickZones) locate(frame string, buttons []button) {
+	c.zones = c.zones[:0]
+	lines := strings.Split(ansi.Strip(frame), "\n")
+	border := lipgloss.RoundedBorder()
+	for _, b := range buttons {
+		row := border.Left + " " + b.label + " " + border.Right
+		for y, line := range lines {
+			i := strings.Index(line, row)
+			if i < 0 || y == 0 {
+				continue
+			}
+			c.zones = append(c.zones, zone{
+				key: b.key,
+				x:   ansi.StringWidth(line[:i]),
+				y:   y - 1,
+				w:   ansi.StringWidth(row),
+				h:   3,
+			})
+			break
+		}
+	}
+}
+
+// hit reports the key of the button at x, y, if any.
+func (c *clickZones) hit(x, y int) (tea.KeyMsg, bool) {
+	for _, z := range c.zones {
+		if x >= z.x && x < z.x+z.w && y >= z.y && y < z.y+z.h {
+			return z.key, true
+		}
+	}
+	return tea.KeyMsg{}, false
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/gen2brain/beeep"
+)
+
+// Notifier delivers the alerts at phase boundaries. The mute mode has
+// already been applied by the time it's called.
+type Notifier interface {
+	// Sound plays the alert for the end of a phase.
+	Sound()
+	// Pip plays the short tone of the final-seconds countdown.
+	Pip()
+	// Notify shows a message to the user. Delivery errors are the
+	// notifier's to swallow: a missing backend mustn't stop the timer.
+	Notify(a Alert)
+}
+
+// Alert is one message for a Notifier.
+type Alert struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	// Phase is the phaseName the timer is in once the alert is sent.
+	Phase   string `json:"phase"`
+	Session int    `json:"session"`
+}
+
+// BeepNotifier is the default: the alert sound (or a beep) and a desktop
+// notification.
+type BeepNotifier struct {
+	sound alertSound
+	icon  string
+}
+
+func (n BeepNotifier) Sound() { playAlert(n.sound) }
+func (n BeepNotifier) Pip()   { playPip() }
+
+func (n BeepNotifier) Notify(a Alert) {
+	_ = beeep.Notify(a.Title, a.Message, n.icon)
+}
+
+// SilentNotifier drops every alert.
+type SilentNotifier struct{}
+
+func (SilentNotifier) Sound()       {}
+func (SilentNotifier) Pip()         {}
+func (SilentNotifier) Notify(Alert) {}
+
+// BellNotifier rings the terminal bell, the one alert that works wherever
+// there's a terminal. A BEL written while bubbletea draws could land inside
+// an escape sequence, so Sound only raises ring and View sends the bell
+// with the next frame.
+type BellNotifier struct {
+	ring *atomic.Bool
+}
+
+func (n BellNotifier) Sound()     { n.ring.Store(true) }
+func (BellNotifier) Pip()         {}
+func (BellNotifier) Notify(Alert) {}
+
+// multiNotifier sends every alert to each of its notifiers in turn.
+type multiNotifier []Notifier
+
+func (ns multiNotifier) Sound() {
+	for _, n := range ns {
+		n.Sound()
+	}
+}
+
+func (ns multiNotifier) Pip() {
+	for _, n := range ns {
+		n.Pip()
+	}
+}
+
+func (ns multiNotifier) Notify(a Alert) {
+	for _, n := range ns {
+		n.Notify(a)
+	}
+}
+
+// newNotifier picks the -notifier backend by name.
+func newNotifier(name string, sound alertSound, icon string) (Notifier, error) {
+	switch name {
+	case "", "beep":
+		return BeepNotifier{sound: sound, icon: icon}, nil
+	case "silent":
+		return SilentNotifier{}, nil
+	}
+	return nil, fmt.Errorf("unknown -notifier %q: want beep or silent", name)
+}
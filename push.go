@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pushTimeout bounds each delivery, so a dead network costs a goroutine
+// for a few seconds and nothing more.
+const pushTimeout = 5 * time.Second
+
+// pushNotifier POSTs each alert to a remote endpoint, for alerts on a
+// phone or in a chat room. It makes no sound.
+type pushNotifier struct {
+	// request builds the POST for an alert.
+	request func(ctx context.Context, a Alert) (*http.Request, error)
+}
+
+// webhookNotifier posts each alert as JSON to url.
+func webhookNotifier(url string) pushNotifier {
+	return pushNotifier{request: func(ctx context.Context, a Alert) (*http.Request, error) {
+		body, err := json.Marshal(a)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}}
+}
+
+// ntfyNotifier publishes each alert to an ntfy topic: a bare name on
+// ntfy.sh, or the full URL of a topic on another server.
+func ntfyNotifier(topic string) pushNotifier {
+	endpoint := topic
+	if !strings.Contains(topic, "://") {
+		endpoint = "https://ntfy.sh/" + topic
+	}
+	return pushNotifier{request: func(ctx context.Context, a Alert) (*http.Request, error) {
+		// The title goes in the query rather than a header so emoji
+		// survive; the message is the plain body.
+		q := url.Values{"title": {a.Title}, "tags": {"tomato," + a.Phase}}
+		return http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"?"+q.Encode(), strings.NewReader(a.Message))
+	}}
+}
+
+func (pushNotifier) Sound() {}
+func (pushNotifier) Pip()   {}
+
+// Notify posts the alert in the background. Failures go to push.log in the data
+// directory, since the terminal belongs to the UI.
+func (n pushNotifier) Notify(a Alert) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+		defer cancel()
+		req, err := n.request(ctx, a)
+		if err == nil {
+			var resp *http.Response
+			if resp, err = http.DefaultClient.Do(req); err == nil {
+				resp.Body.Close()
+				if resp.StatusCode >= 300 {
+					err = fmt.Errorf("%s: %s", req.URL, resp.Status)
+				}
+			}
+		}
+		if err != nil {
+			logPushError(err)
+		}
+	}()
+}
+
+// logPushError appends a failed delivery to push.log. Failures to log
+// are ignored too.
+func logPushError(err error) {
+	dir, derr := dataDir()
+	if derr != nil {
+		return
+	}
+	if os.MkdirAll(dir, 0o755) != nil {
+		return
+	}
+	f, ferr := os.OpenFile(filepath.Join(dir, "push.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if ferr != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s %v\n", time.Now().Format(time.RFC3339), err)
+}
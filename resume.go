@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// savedState is the running timer as written to the state file, enough to
+// pick up where it left off after a crash or a closed terminal.
+type savedState struct {
+	SavedAt time.Time `json:"saved_at"`
+	// PID is the process that wrote the file, for 'pomo next' to tell a
+	// running timer from one that was closed.
+	PID int `json:"pid,omitempty"`
+
+	Work      time.Duration `json:"work"`
+	Break     time.Duration `json:"break"`
+	LongBreak time.Duration `json:"long_break"`
+	Sessions  int           `json:"sessions"`
+	Budget    time.Duration `json:"budget,omitempty"`
+	Repeat    bool          `json:"repeat"`
+	Task      string        `json:"task,omitempty"`
+
+	OnBreak       bool          `json:"on_break"`
+	Long          bool          `json:"long"`
+	Session       int           `json:"session"`
+	Cycle         int           `json:"cycle"`
+	TimeLeft      time.Duration `json:"time_left"`
+	PhaseDuration time.Duration `json:"phase_duration"`
+	PhaseElapsed  time.Duration `json:"phase_elapsed"`
+	PhasePaused   time.Duration `json:"phase_paused"`
+	PhasePauses   int           `json:"phase_pauses,omitempty"`
+	Paused        bool          `json:"paused"`
+	Waiting       bool          `json:"waiting"`
+
+	Interjected          bool          `json:"interjected,omitempty"`
+	StashedTimeLeft      time.Duration `json:"stashed_time_left,omitempty"`
+	StashedPhaseDuration time.Duration `json:"stashed_phase_duration,omitempty"`
+	StashedElapsed       time.Duration `json:"stashed_elapsed,omitempty"`
+	StashedPaused        time.Duration `json:"stashed_paused,omitempty"`
+	StashedPauses        int           `json:"stashed_pauses,omitempty"`
+
+	Overtime     bool          `json:"overtime,omitempty"`
+	Overtimes    int           `json:"overtimes,omitempty"`
+	OvertimeWork time.Duration `json:"overtime_work,omitempty"`
+
+	FocusedTotal    time.Duration `json:"focused_total"`
+	BudgetBase      time.Duration `json:"budget_base,omitempty"`
+	BreakTotal      time.Duration `json:"break_total"`
+	SessionsDone    int           `json:"sessions_done"`
+	SessionsSkipped int           `json:"sessions_skipped,omitempty"`
+}
+
+// maxResumeAge is how old a state file can be and still be resumed.
+const maxResumeAge = 24 * time.Hour
+
+// stateSaveEvery is how often the state file is rewritten while running.
+const stateSaveEvery = 5 * time.Second
+
+func statePath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
+// stateFile keeps the state file up to date while the timer runs and
+// removes it once the run is over.
+type stateFile struct {
+	path    string
+	last    time.Time
+	paused  bool
+	written bool
+}
+
+func (f *stateFile) update(m model) {
+	if m.completed || m.state == stateSetup {
+		if f.written {
+			_ = os.Remove(f.path)
+			f.written = false
+		}
+		return
+	}
+	// The warmup is over in seconds, and nothing is lost with it.
+	if m.countUp || m.timerType == typeWarmup {
+		return
+	}
+	now := time.Now()
+	if f.written && m.paused == f.paused && now.Sub(f.last) < stateSaveEvery {
+		return
+	}
+	data, err := json.Marshal(m.savedState(now))
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".pomo-state-*")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil || os.Rename(tmp.Name(), f.path) != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	f.last, f.paused, f.written = now, m.paused, true
+}
+
+func (m model) savedState(now time.Time) savedState {
+	return savedState{
+		SavedAt:   now,
+		PID:       os.Getpid(),
+		Work:      m.workDuration,
+		Break:     m.breakDuration,
+		LongBreak: m.longBreakDuration,
+		Sessions:  m.sessionsTotal,
+		Budget:    m.budget,
+		Repeat:    m.repeat,
+		Task:      m.task,
+
+		OnBreak:       m.timerType == typeBreak,
+		Long:          m.longBreak,
+		Session:       m.currentSession,
+		Cycle:         m.cycle,
+		TimeLeft:      m.timeLeft,
+		PhaseDuration: m.currentPhaseDuration,
+		PhaseElapsed:  m.phaseElapsed,
+		PhasePaused:   m.pausedAccumulated,
+		PhasePauses:   m.pauses,
+		Paused:        m.paused,
+		Waiting:       m.waitingToStart || m.awaitingAck,
+
+		Interjected:          m.interjected,
+		StashedTimeLeft:      m.stashedTimeLeft,
+		StashedPhaseDuration: m.stashedPhaseDuration,
+		StashedElapsed:       m.stashedElapsed,
+		StashedPaused:        m.stashedPaused,
+		StashedPauses:        m.stashedPauses,
+
+		Overtime:     m.overtime,
+		Overtimes:    m.overtimes,
+		OvertimeWork: m.overtimeWork,
+
+		FocusedTotal:    m.focusedTotal,
+		BudgetBase:      m.budgetBase,
+		BreakTotal:      m.breakTotal,
+		SessionsDone:    m.sessionsDone,
+		SessionsSkipped: m.sessionsSkipped,
+	}
+}
+
+// loadState reads the state file left behind by an unfinished run.
+func loadState() (savedState, error) {
+	var st savedState
+	path, err := statePath()
+	if err != nil {
+		return st, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return st, nil
+}
+
+var errNothingToResume = errors.New("nothing to resume")
+
+var errNoActive = errors.New("no active pomodoro")
+
+// runNext prints how long is left until the running timer moves on, e.g.
+// "12m30s until break", for scripts and status checks.
+func runNext(w io.Writer, now time.Time) error {
+	st, err := loadState()
+	if errors.Is(err, fs.ErrNotExist) {
+		return errNoActive
+	}
+	if err != nil {
+		return err
+	}
+	if st.PID == 0 || !processAlive(st.PID) {
+		return errNoActive
+	}
+	fmt.Fprintln(w, st.next(now))
+	return nil
+}
+
+// next describes the time left in the saved phase and what follows it.
+func (st savedState) next(now time.Time) string {
+	left := st.TimeLeft
+	if !st.Paused {
+		left -= now.Sub(st.SavedAt)
+	}
+	upcoming := "break"
+	switch {
+	case st.OnBreak:
+		upcoming = "work"
+	case st.Session >= st.Sessions && !st.Repeat:
+		upcoming = "done"
+	}
+	line := fmt.Sprintf("%s until %s", formatDuration(max(left, 0)), upcoming)
+	if st.Paused {
+		line += " (paused)"
+	}
+	return line
+}
+
+// resumable reports whether st can still be picked up at now. A running
+// phase loses the time that passed while the app was gone.
+func (st savedState) resumable(now time.Time, clock12 bool) error {
+	if now.Sub(st.SavedAt) > maxResumeAge {
+		saved := st.SavedAt.Local()
+		return fmt.Errorf("%w: the saved session is from %s %s", errNothingToResume, saved.Format("Mon Jan 2"), formatClock(saved, clock12))
+	}
+	if !st.Paused && st.TimeLeft-now.Sub(st.SavedAt) <= 0 {
+		return fmt.Errorf("%w: the saved session ran out while the timer was closed", errNothingToResume)
+	}
+	return nil
+}
+
+// restore puts m back in the saved state, counting the time since it was
+// saved against the running phase.
+func (m model) restore(st savedState, now time.Time) model {
+	m.state = stateRunning
+	m.workDuration = st.Work
+	m.breakDuration = st.Break
+	m.longBreakDuration = st.LongBreak
+	m.sessionsTotal = st.Sessions
+	m.budget = st.Budget
+	m.repeat = st.Repeat
+	m.task = st.Task
+
+	m.timerType = typeWork
+	if st.OnBreak {
+		m.timerType = typeBreak
+	}
+	m.longBreak = st.Long
+	m.currentSession = st.Session
+	m.cycle = st.Cycle
+	left, elapsed := st.TimeLeft, st.PhaseElapsed
+	if !st.Paused {
+		gone := now.Sub(st.SavedAt)
+		left -= gone
+		elapsed += gone
+	}
+	m.setPhaseTime(left)
+	m.currentPhaseDuration = st.PhaseDuration
+	m.phaseElapsed = elapsed
+	m.pausedAccumulated = st.PhasePaused
+	m.pauses = st.PhasePauses
+	m.paused = st.Paused
+	m.waitingToStart = st.Waiting
+	m.pausedAt = now
+
+	m.interjected = st.Interjected
+	m.stashedTimeLeft = st.StashedTimeLeft
+	m.stashedPhaseDuration = st.StashedPhaseDuration
+	m.stashedElapsed = st.StashedElapsed
+	m.stashedPaused = st.StashedPaused
+	m.stashedPauses = st.StashedPauses
+
+	m.overtime = st.Overtime
+	m.overtimes = st.Overtimes
+	m.overtimeWork = st.OvertimeWork
+
+	m.focusedTotal = st.FocusedTotal
+	m.budgetBase = st.BudgetBase
+	m.breakTotal = st.BreakTotal
+	m.sessionsDone = st.SessionsDone
+	m.sessionsSkipped = st.SessionsSkipped
+	m.timerID++
+	return m
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The ring is a thin frame of half-block characters around the block-font
+// clock, two columns and one row clear of the digits. It fills clockwise
+// from the top centre as the phase elapses.
+const (
+	ringPadX = 2
+	ringPadY = 1
+)
+
+// ringWidth is how many columns renderRing needs for d.
+func ringWidth(d time.Duration, f font) int {
+	return lipgloss.Width(bigTimeLines(d, f)[0]) + 2*ringPadX + 2
+}
+
+// ringCell is one position on the frame.
+type ringCell struct{ row, col int }
+
+// ringPath lists the frame cells of a w×h box clockwise, starting at the
+// middle of the top edge.
+func ringPath(w, h int) []ringCell {
+	var path []ringCell
+	for c := w / 2; c < w; c++ {
+		path = append(path, ringCell{0, c})
+	}
+	for r := 1; r < h-1; r++ {
+		path = append(path, ringCell{r, w - 1})
+	}
+	for c := w - 1; c >= 0; c-- {
+		path = append(path, ringCell{h - 1, c})
+	}
+	for r := h - 2; r > 0; r-- {
+		path = append(path, ringCell{r, 0})
+	}
+	for c := 0; c < w/2; c++ {
+		path = append(path, ringCell{0, c})
+	}
+	return path
+}
+
+// ringGlyph picks the half block for a frame cell so the line hugs the
+// inside edge of the box.
+func ringGlyph(cell ringCell, w, h int) string {
+	top, bottom := cell.row == 0, cell.row == h-1
+	left, right := cell.col == 0, cell.col == w-1
+	switch {
+	case top && left:
+		return "▗"
+	case top && right:
+		return "▖"
+	case bottom && left:
+		return "▝"
+	case bottom && right:
+		return "▘"
+	case top:
+		return "▄"
+	case bottom:
+		return "▀"
+	case left:
+		return "▐"
+	default:
+		return "▌"
+	}
+}
+
+// renderRing draws d in font f inside a ring showing how much of total
+// has elapsed, given what is left.
+func renderRing(d time.Duration, f font, left, total time.Duration, color, empty lipgloss.TerminalColor) string {
+	clock := bigTimeLines(d, f)
+	w := lipgloss.Width(clock[0]) + 2*ringPadX + 2
+	h := len(clock) + 2*ringPadY + 2
+
+	frac := 0.0
+	if total > 0 {
+		frac = 1 - float64(left)/float64(total)
+	}
+	frac = math.Max(0, math.Min(1, frac))
+	path := ringPath(w, h)
+	filled := int(frac * float64(len(path)))
+
+	on := lipgloss.NewStyle().Foreground(color)
+	off := lipgloss.NewStyle().Foreground(empty)
+	// sides holds the left and right glyph of each middle row.
+	sides := make([][2]string, h)
+	top := make([]string, w)
+	bottom := make([]string, w)
+	for i, cell := range path {
+		style := off
+		if i < filled {
+			style = on
+		}
+		glyph := style.Render(ringGlyph(cell, w, h))
+		switch {
+		case cell.row == 0:
+			top[cell.col] = glyph
+		case cell.row == h-1:
+			bottom[cell.col] = glyph
+		case cell.col == 0:
+			sides[cell.row][0] = glyph
+		default:
+			sides[cell.row][1] = glyph
+		}
+	}
+
+	inner := w - 2
+	lines := make([]string, h)
+	lines[0] = strings.Join(top, "")
+	lines[h-1] = strings.Join(bottom, "")
+	for r := 1; r < h-1; r++ {
+		middle := strings.Repeat(" ", inner)
+		if i := r - 1 - ringPadY; i >= 0 && i < len(clock) {
+			pad := strings.Repeat(" ", ringPadX)
+			middle = pad + on.Render(clock[i]) + pad
+		}
+		lines[r] = sides[r][0] + middle + sides[r][1]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// statusSnapshot is the externally visible state of the timer.
+type statusSnapshot struct {
+	Phase    string `json:"phase"`
+	TimeLeft int    `json:"time_left_seconds"`
+	Session  int    `json:"session"`
+	Total    int    `json:"total"`
+	Repeat   bool   `json:"repeat"`
+	Paused   bool   `json:"paused"`
+}
+
+// sharedStatus hands the latest snapshot from the UI goroutine to readers.
+type sharedStatus struct {
+	mu   sync.Mutex
+	snap statusSnapshot
+}
+
+func (s *sharedStatus) set(snap statusSnapshot) {
+	s.mu.Lock()
+	s.snap = snap
+	s.mu.Unlock()
+}
+
+func (s *sharedStatus) get() statusSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snap
+}
+
+// phaseName is a stable, machine-friendly name for what the timer is doing.
+func (m model) phaseName() string {
+	switch {
+	case m.state == stateSetup:
+		return "setup"
+	case m.state == stateDone:
+		return "done"
+	case m.countUp:
+		return "stopwatch"
+	case m.inMicroBreak:
+		return "micro_break"
+	case m.timerType == typeWarmup:
+		return "warmup"
+	case m.timerType == typeBreak && m.longBreak:
+		return "long_break"
+	case m.timerType == typeBreak:
+		return "break"
+	}
+	return "work"
+}
+
+func (m model) snapshot() statusSnapshot {
+	left := m.timeLeft
+	if m.countUp {
+		left = m.timeElapsed
+	} else if m.inMicroBreak {
+		left = m.microLeft
+	}
+	return statusSnapshot{
+		Phase:    m.phaseName(),
+		TimeLeft: int(left.Seconds()),
+		Session:  m.currentSession,
+		Total:    m.sessionsTotal,
+		Repeat:   m.repeat,
+		Paused:   m.paused,
+	}
+}
+
+// startStatusServer serves the shared status as JSON on addr. The listener
+// is bound before returning so a busy port is reported up front.
+func startStatusServer(addr string, status *sharedStatus) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(status.get())
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	return srv, nil
+}
+
+func stopStatusServer(srv *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = srv.Shutdown(ctx)
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package main
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// forwardSignals does nothing: there's no SIGUSR1 or SIGUSR2 here.
+func forwardSignals(*tea.Program) func() {
+	return func() {}
+}
+
+func ignoreSignals() {}
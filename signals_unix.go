@@ -0,0 +1,38 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// forwardSignals hands SIGUSR1 (pause/resume) and SIGUSR2 (skip) to p, so
+// scripts such as a screen-lock hook can drive the timer. The returned
+// func stops listening.
+func forwardSignals(p *tea.Program) func() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range ch {
+			if sig == syscall.SIGUSR1 {
+				p.Send(signalMsg{keySpace})
+			} else {
+				p.Send(signalMsg{keySkip})
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(ch)
+	}
+}
+
+// ignoreSignals keeps SIGUSR1 and SIGUSR2 from killing a run that has
+// nothing to forward them to.
+func ignoreSignals() {
+	signal.Ignore(syscall.SIGUSR1, syscall.SIGUSR2)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gen2brain/beeep"
+)
+
+// alertSound is how the end-of-phase alert sounds.
+type alertSound struct {
+	path     string  // sound file; empty for the platform default or a beep
+	freq     float64 // beep tone in Hz, 0 for beeep's default
+	duration int     // beep length in milliseconds, 0 for beeep's default
+	volume   int     // player volume in percent; 100 leaves it alone
+	tones    []tone  // a -sound-theme pattern, used when there's no file
+}
+
+// tone is one beep of a sound theme.
+type tone struct {
+	freq     float64 // Hz
+	duration int     // milliseconds
+}
+
+// soundTheme is a bundled alert made of beeps.
+type soundTheme struct {
+	name  string
+	desc  string
+	tones []tone
+}
+
+// toneGap is the pause between the beeps of a theme.
+const toneGap = 80 * time.Millisecond
+
+var soundThemes = []soundTheme{
+	{"bell", "one long, bright ring", []tone{{1320, 600}}},
+	{"chime", "three rising notes", []tone{{523, 150}, {659, 150}, {784, 300}}},
+	{"digital", "four quick watch beeps", []tone{{2000, 80}, {2000, 80}, {2000, 80}, {2000, 80}}},
+	{"gentle", "two soft, low tones", []tone{{440, 200}, {392, 300}}},
+}
+
+// findSoundTheme looks a sound theme up by name.
+func findSoundTheme(name string) (soundTheme, bool) {
+	for _, t := range soundThemes {
+		if strings.EqualFold(t.name, name) {
+			return t, true
+		}
+	}
+	return soundTheme{}, false
+}
+
+// printSoundThemes lists the sound themes for 'pomo sounds'.
+func printSoundThemes(w io.Writer) {
+	for _, t := range soundThemes {
+		fmt.Fprintf(w, "%-8s %s\n", t.name, t.desc)
+	}
+}
+
+// playTones plays a theme's beeps in order, blocking until they're done.
+func playTones(tones []tone) error {
+	for i, t := range tones {
+		if i > 0 {
+			time.Sleep(toneGap)
+		}
+		if err := beeep.Beep(t.freq, t.duration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Beep settings outside these ranges are clamped rather than rejected.
+const (
+	minBeepFreq, maxBeepFreq         = 100, 5000
+	minBeepDuration, maxBeepDuration = 50, 5000
+	maxVolume                        = 200
+)
+
+// clamped returns s with its beep and volume settings pulled into range.
+// Zero values keep meaning "the default".
+func (s alertSound) clamped() alertSound {
+	if s.freq != 0 {
+		s.freq = min(max(s.freq, minBeepFreq), maxBeepFreq)
+	}
+	if s.duration != 0 {
+		s.duration = min(max(s.duration, minBeepDuration), maxBeepDuration)
+	}
+	s.volume = min(max(s.volume, 0), maxVolume)
+	return s
+}
+
+// beep sounds the fallback tone.
+func (s alertSound) beep() error {
+	freq, duration := s.freq, s.duration
+	if freq == 0 {
+		freq = beeep.DefaultFreq
+	}
+	if duration == 0 {
+		duration = beeep.DefaultDuration
+	}
+	return beeep.Beep(freq, duration)
+}
+
+// windowsDefaultSound is played on Windows when no -sound file is given.
+const windowsDefaultSound = `C:\Windows\Media\Windows Notify System Generic.wav`
+
+// soundCommand returns the command line that plays the alert on this
+// platform, or nil when no player is available. An empty path selects the
+// platform's default alert sound, which only exists on Windows. Volume is
+// passed on to players that take one (paplay and afplay).
+func soundCommand(s alertSound) []string {
+	path := s.path
+	switch runtime.GOOS {
+	case "windows":
+		if path == "" {
+			path = windowsDefaultSound
+		}
+		quoted := "'" + strings.ReplaceAll(path, "'", "''") + "'"
+		return []string{"powershell", "-c", "(New-Object Media.SoundPlayer " + quoted + ").PlaySync()"}
+	case "darwin":
+		if path != "" && hasCommand("afplay") {
+			if s.volume != 100 {
+				return []string{"afplay", "-v", strconv.FormatFloat(float64(s.volume)/100, 'f', 2, 64), path}
+			}
+			return []string{"afplay", path}
+		}
+	default:
+		if path == "" {
+			return nil
+		}
+		if hasCommand("paplay") {
+			if s.volume != 100 {
+				// paplay's volume is linear with 65536 as 100%.
+				return []string{"paplay", "--volume=" + strconv.Itoa(s.volume*65536/100), path}
+			}
+			return []string{"paplay", path}
+		}
+		if hasCommand("aplay") {
+			return []string{"aplay", path}
+		}
+	}
+	return nil
+}
+
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// playAlert plays the boundary alert in the background.
+func playAlert(s alertSound) {
+	go func() { _, _ = playAlertSync(s) }()
+}
+
+// playAlertSync plays the sound file through the platform player when one
+// is found, or the -sound-theme beeps, otherwise a plain beep. It reports
+// what it ran.
+func playAlertSync(s alertSound) (string, error) {
+	// A sound file beats a theme, and a theme beats the platform default.
+	if s.path == "" && len(s.tones) > 0 {
+		return "sound theme", playTones(s.tones)
+	}
+	if argv := soundCommand(s); argv != nil {
+		if err := exec.Command(argv[0], argv[1:]...).Run(); err == nil {
+			return strings.Join(argv, " "), nil
+		}
+	}
+	return "beep", s.beep()
+}
+
+// pipPlaying guards against stacking pip goroutines if the audio backend
+// is slower than the tick rate.
+var pipPlaying atomic.Bool
+
+// playPip plays the short, low tone used for the final-seconds countdown.
+func playPip() {
+	if !pipPlaying.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer pipPlaying.Store(false)
+		_ = beeep.Beep(beeep.DefaultFreq*3/4, 60)
+	}()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// statusFile mirrors a one-line summary of the timer into a file for status
+// bars such as tmux or polybar.
+type statusFile struct {
+	path string
+	last string
+}
+
+// statusLine is the summary written to the status file, e.g. "🍅 23:14 (2/4)".
+func (m model) statusLine() string {
+	if m.state == stateSetup {
+		return "idle"
+	}
+	total := fmt.Sprint(m.sessionsTotal)
+	if m.repeat {
+		total = "∞"
+	}
+	var line string
+	switch m.phaseName() {
+	case "stopwatch":
+		line = fmt.Sprintf("⏱ %s (#%d)", clockString(m.timeElapsed), m.currentSession)
+	case "done":
+		line = "✅ done"
+	case "warmup":
+		line = fmt.Sprintf("⏳ %s", clockString(m.timeLeft))
+	case "micro_break":
+		line = fmt.Sprintf("👀 %s (%d/%s)", clockString(m.microLeft), m.currentSession, total)
+	case "break", "long_break":
+		line = fmt.Sprintf("☕ %s (%d/%s)", clockString(m.timeLeft), m.currentSession, total)
+	default:
+		line = fmt.Sprintf("🍅 %s (%d/%s)", clockString(m.timeLeft), m.currentSession, total)
+	}
+	if m.paused {
+		line += " ⏸"
+	}
+	return line
+}
+
+// write replaces the file contents with line, skipping unchanged lines.
+// It writes to a temp file and renames it so readers never see a partial
+// line. Errors are ignored; the status file is a convenience.
+func (f *statusFile) write(line string) {
+	if line == f.last {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".pomo-status-*")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.WriteString(line + "\n")
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil || os.Rename(tmp.Name(), f.path) != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	f.last = line
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// version is the release this binary was built from. Release builds set
+// it with -ldflags "-X main.version=v1.2.3"; otherwise it comes from the
+// module build info, which has it for "go install ...@v1.2.3".
+var version = ""
+
+// buildInfo is what 'pomo version' reports.
+type buildInfo struct {
+	version  string
+	commit   string // empty when built outside a git checkout
+	time     string // commit time, RFC 3339
+	modified bool   // built with uncommitted changes
+}
+
+func readBuildInfo() buildInfo {
+	b := buildInfo{version: version}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if b.version == "" {
+			b.version = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				b.commit = s.Value
+			case "vcs.time":
+				b.time = s.Value
+			case "vcs.modified":
+				b.modified = s.Value == "true"
+			}
+		}
+	}
+	// A plain "go build" reports "(devel)".
+	if b.version == "" || b.version == "(devel)" {
+		b.version = "dev"
+	}
+	return b
+}
+
+// printVersion writes the build info as "key: value" lines after the
+// first, so scripts can pick out what they need.
+func printVersion(w io.Writer) {
+	b := readBuildInfo()
+	fmt.Fprintf(w, "pomo %s\n", b.version)
+	if b.commit != "" {
+		commit := b.commit
+		if b.modified {
+			commit += " (modified)"
+		}
+		fmt.Fprintf(w, "commit: %s\n", commit)
+	}
+	if b.time != "" {
+		fmt.Fprintf(w, "date:   %s\n", b.time)
+	}
+	fmt.Fprintf(w, "go:     %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}